@@ -6,11 +6,14 @@ import (
 
 	"github.com/ar4ie13/loyaltysystem/internal/auth"
 	"github.com/ar4ie13/loyaltysystem/internal/config"
+	grpcserver "github.com/ar4ie13/loyaltysystem/internal/grpc"
 	"github.com/ar4ie13/loyaltysystem/internal/handlers"
 	"github.com/ar4ie13/loyaltysystem/internal/logger"
+	"github.com/ar4ie13/loyaltysystem/internal/metrics"
 	"github.com/ar4ie13/loyaltysystem/internal/repository"
 	"github.com/ar4ie13/loyaltysystem/internal/requestor"
 	"github.com/ar4ie13/loyaltysystem/internal/service"
+	"github.com/ar4ie13/loyaltysystem/internal/webhooks"
 )
 
 func main() {
@@ -23,13 +26,22 @@ func run() error {
 	cfg := config.NewConfig()
 	zlog := logger.NewLogger(cfg.LogConf.Level)
 	authorize := auth.NewAuth(cfg.AuthConf)
-	repo, err := repository.NewRepository(context.Background(), cfg.PGConf, zlog.Logger)
+	mtr := metrics.NewCollector()
+	repo, err := repository.NewRepository(context.Background(), cfg.PGConf, zlog.Logger, mtr)
 	if err != nil {
 		return err
 	}
-	srv := service.NewService(repo, zlog.Logger)
-	hndlr := handlers.NewHandlers(cfg.ServerConf, authorize, srv, zlog.Logger)
-	requestor.NewRequestor(cfg.AccrualConf, zlog.Logger, repo)
+	srv := service.NewService(repo, zlog.Logger, cfg.AuthConf.RefreshTokenExpiration)
+	hndlr := handlers.NewHandlers(cfg.ServerConf, authorize, srv, zlog.Logger, mtr)
+	requestor.NewRequestor(cfg.AccrualConf, zlog.Logger, repo, mtr)
+	webhooks.NewDispatcher(cfg.WebhookConf, zlog.Logger, repo)
+
+	go func() {
+		if grpcErr := grpcserver.ListenAndServe(cfg.GRPCConf, zlog.Logger, authorize, srv); grpcErr != nil {
+			zlog.Error().Err(grpcErr).Msg("grpc server stopped")
+		}
+	}()
+
 	if err = hndlr.ListenAndServe(); err != nil {
 		return err
 	}
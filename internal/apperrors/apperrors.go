@@ -1,13 +1,62 @@
 package apperrors
 
-import "errors"
+import "net/http"
+
+// AppError is a typed application error carrying a stable, machine-readable code and the HTTP
+// status it maps to, so the API can surface a structured error envelope to clients. Err holds an
+// optional underlying cause (a bind failure, a driver error) that is reported in the envelope's
+// "details" but does not affect the code or status.
+type AppError struct {
+	Code       string
+	HTTPStatus int
+	Message    string
+	Err        error
+}
+
+// Error satisfies the error interface
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes the underlying cause, if any, to errors.Is/errors.As
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// WithErr returns a copy of the AppError carrying err as its underlying cause
+func (e *AppError) WithErr(err error) *AppError {
+	return &AppError{Code: e.Code, HTTPStatus: e.HTTPStatus, Message: e.Message, Err: err}
+}
 
 var (
-	ErrUserNotFound        = errors.New("user not found")
-	ErrUserAlreadyExists   = errors.New("user already exists")
-	ErrInvalidUserUUID     = errors.New("invalid user uuid")
-	ErrUserIsNotAuthorized = errors.New("user is not authorized")
-	ErrInvalidLoginString  = errors.New("invalid login string, use letters and digits only")
-	ErrPasswordMinSymbols  = errors.New("password minimum symbols")
-	ErrInvalidPassword     = errors.New("invalid password")
+	ErrUserNotFound        = &AppError{Code: "USER_NOT_FOUND", HTTPStatus: http.StatusNotFound, Message: "user not found"}
+	ErrUserAlreadyExists   = &AppError{Code: "USER_ALREADY_EXISTS", HTTPStatus: http.StatusConflict, Message: "user already exists"}
+	ErrInvalidUserUUID     = &AppError{Code: "INVALID_USER_UUID", HTTPStatus: http.StatusBadRequest, Message: "invalid user uuid"}
+	ErrUserIsNotAuthorized = &AppError{Code: "UNAUTHORIZED", HTTPStatus: http.StatusUnauthorized, Message: "user is not authorized"}
+	ErrInvalidLoginString  = &AppError{Code: "INVALID_LOGIN", HTTPStatus: http.StatusBadRequest, Message: "invalid login string, use letters and digits only"}
+	ErrPasswordMinSymbols  = &AppError{Code: "PASSWORD_TOO_SHORT", HTTPStatus: http.StatusBadRequest, Message: "password does not meet the minimum length"}
+	ErrInvalidPassword     = &AppError{Code: "INVALID_PASSWORD", HTTPStatus: http.StatusUnauthorized, Message: "invalid password"}
+
+	ErrRefreshTokenNotFound = &AppError{Code: "REFRESH_TOKEN_NOT_FOUND", HTTPStatus: http.StatusUnauthorized, Message: "refresh token not found"}
+	ErrRefreshTokenExpired  = &AppError{Code: "REFRESH_TOKEN_EXPIRED", HTTPStatus: http.StatusUnauthorized, Message: "refresh token expired"}
+	ErrRefreshTokenReused   = &AppError{Code: "REFRESH_TOKEN_REUSED", HTTPStatus: http.StatusUnauthorized, Message: "refresh token already used"}
+
+	ErrForbidden   = &AppError{Code: "FORBIDDEN", HTTPStatus: http.StatusForbidden, Message: "caller is not allowed to perform this action"}
+	ErrRateLimited = &AppError{Code: "RATE_LIMITED", HTTPStatus: http.StatusTooManyRequests, Message: "too many requests"}
+
+	ErrBalanceNotEnough       = &AppError{Code: "BALANCE_NOT_ENOUGH", HTTPStatus: http.StatusPaymentRequired, Message: "insufficient balance"}
+	ErrNoOrders               = &AppError{Code: "NO_ORDERS", HTTPStatus: http.StatusNoContent, Message: "no orders found"}
+	ErrOrderAlreadyExists     = &AppError{Code: "ORDER_ALREADY_EXISTS", HTTPStatus: http.StatusOK, Message: "order already registered by this user"}
+	ErrIncorrectOrderNumber   = &AppError{Code: "INCORRECT_ORDER_NUMBER", HTTPStatus: http.StatusUnprocessableEntity, Message: "order number fails the Luhn check"}
+	ErrOrderNumberAlreadyUsed = &AppError{Code: "ORDER_NUMBER_ALREADY_USED", HTTPStatus: http.StatusConflict, Message: "order number already registered by another user"}
+	ErrWithdrawnNotPositive   = &AppError{Code: "WITHDRAWN_NOT_POSITIVE", HTTPStatus: http.StatusBadRequest, Message: "withdrawn amount must be greater than zero"}
+
+	ErrBadRequest = &AppError{Code: "BAD_REQUEST", HTTPStatus: http.StatusBadRequest, Message: "invalid request"}
+	ErrInternal   = &AppError{Code: "INTERNAL", HTTPStatus: http.StatusInternalServerError, Message: "internal server error"}
+
+	ErrInvalidWebhookURL = &AppError{Code: "INVALID_WEBHOOK_URL", HTTPStatus: http.StatusBadRequest, Message: "webhook url must be an absolute http(s) url"}
+	ErrWebhookNotFound   = &AppError{Code: "WEBHOOK_NOT_FOUND", HTTPStatus: http.StatusNotFound, Message: "webhook subscription not found"}
 )
@@ -1,49 +1,75 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
 	authconf "github.com/ar4ie13/loyaltysystem/internal/auth/config"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Auth struct {
-	Claims Claims
-	conf   authconf.Config
+	Claims         Claims
+	conf           authconf.Config
+	oauthProviders map[string]OAuthProvider
+	stateStore     StateStore
 }
 
 type Claims struct {
 	jwt.RegisteredClaims
 	UserUUID uuid.UUID
+	Role     role.Role
 }
 
 // NewAuth creates Auth object
 func NewAuth(conf authconf.Config) *Auth {
+	providers := make(map[string]OAuthProvider, len(conf.OAuthProviders))
+	for name, providerConf := range conf.OAuthProviders {
+		providers[name] = NewOAuthProvider(name, providerConf)
+	}
+
 	return &Auth{
-		conf: conf,
+		conf:           conf,
+		oauthProviders: providers,
+		stateStore:     NewMemoryStateStore(),
 	}
 }
 
+// OAuthProvider returns the registered OAuthProvider for the given name
+func (a Auth) OAuthProvider(name string) (OAuthProvider, bool) {
+	p, ok := a.oauthProviders[name]
+	return p, ok
+}
+
+// StateStore returns the store used to validate OAuth state tokens across the login/callback round-trip
+func (a Auth) StateStore() StateStore {
+	return a.stateStore
+}
+
 // GenerateUserUUID generates new UUID for user
 func (a Auth) GenerateUserUUID() uuid.UUID {
 	return uuid.New()
 }
 
-// BuildJWTString creates new JWT token
-func (a Auth) BuildJWTString(userUUID uuid.UUID) (string, error) {
+// BuildJWTString creates new JWT token, tagging it with a unique jti so it can be individually revoked
+func (a Auth) BuildJWTString(userUUID uuid.UUID, userRole role.Role) (string, error) {
 	// creating new token with HS256 algorithm and claims — Auth
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			// token expiration date
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.conf.TokenExpiration)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.conf.AccessTokenExpiration)),
+			ID:        uuid.NewString(),
 		},
-		// personal claim
+		// personal claims
 		UserUUID: userUUID,
+		Role:     userRole,
 	})
 
 	// creating signed token string
@@ -76,6 +102,50 @@ func (a Auth) ValidateUserUUID(tokenString string) (uuid.UUID, error) {
 	return claims.UserUUID, nil
 }
 
+// ParseJTI extracts the jti (JWT ID) claim from an access token string, used for revocation checks
+func (a Auth) ParseJTI(tokenString string) (string, error) {
+	claims, _, err := a.parseTokenString(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.ID, nil
+}
+
+// ParseRole extracts the Role claim from an access token string
+func (a Auth) ParseRole(tokenString string) (role.Role, error) {
+	claims, _, err := a.parseTokenString(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.Role, nil
+}
+
+// TokenExpiresAt returns the expiration time of a previously issued access token
+func (a Auth) TokenExpiresAt(tokenString string) (time.Time, error) {
+	claims, _, err := a.parseTokenString(tokenString)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, fmt.Errorf("token has no expiration claim")
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+// RefreshTokenExpiration returns the configured lifetime of a refresh token
+func (a Auth) RefreshTokenExpiration() time.Duration {
+	return a.conf.RefreshTokenExpiration
+}
+
+// GenerateOpaqueToken creates a cryptographically random opaque token, used as the refresh token value
+func (a Auth) GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 // parseTokenString parses token string and returns claims and token (for validation)
 func (a Auth) parseTokenString(tokenString string) (*Claims, *jwt.Token, error) {
 	claims := &Claims{}
@@ -93,7 +163,7 @@ func (a Auth) parseTokenString(tokenString string) (*Claims, *jwt.Token, error)
 
 func (a Auth) GenerateHashFromPassword(password string) (string, error) {
 	if len(password) < a.conf.PasswordLen {
-		return "", fmt.Errorf("%w: should be %d", apperrors.ErrPasswordMinSymbols, a.conf.PasswordLen)
+		return "", apperrors.ErrPasswordMinSymbols.WithErr(fmt.Errorf("password must be at least %d characters", a.conf.PasswordLen))
 	}
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	return string(bytes), err
@@ -4,7 +4,26 @@ import "time"
 
 // Config object for authentication service
 type Config struct {
-	SecretKey       string
+	SecretKey string
+
+	// AccessTokenExpiration is the lifetime of the short-lived JWT access token.
+	AccessTokenExpiration time.Duration
+	// Deprecated: TokenExpiration is the old name for AccessTokenExpiration, kept only so existing -e flags
+	// and TOKEN_EXPIRATION environment variables continue to parse; set AccessTokenExpiration instead.
 	TokenExpiration time.Duration
-	PasswordLen     int
+
+	RefreshTokenExpiration time.Duration
+	PasswordLen            int
+	OAuthProviders         map[string]OAuthProviderConfig
+	OAuthStateTTL          time.Duration
+}
+
+// OAuthProviderConfig holds the per-IdP OAuth2 authorization-code settings
+type OAuthProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
 }
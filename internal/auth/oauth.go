@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	authconf "github.com/ar4ie13/loyaltysystem/internal/auth/config"
+	"golang.org/x/oauth2"
+)
+
+// oauthProvider is the generic OAuth2 authorization-code implementation shared by every IdP
+type oauthProvider struct {
+	name        string
+	oauth2Conf  oauth2.Config
+	userInfoURL string
+}
+
+// NewOAuthProvider builds an OAuthProvider for the given named IdP configuration
+func NewOAuthProvider(name string, cfg authconf.OAuthProviderConfig) OAuthProvider {
+	return &oauthProvider{
+		name: name,
+		oauth2Conf: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.AuthURL,
+				TokenURL: cfg.TokenURL,
+			},
+			Scopes: []string{"openid", "profile", "email"},
+		},
+		userInfoURL: cfg.UserInfoURL,
+	}
+}
+
+// AuthCodeURL returns the IdP redirect URL for the given opaque state
+func (p *oauthProvider) AuthCodeURL(state string) string {
+	return p.oauth2Conf.AuthCodeURL(state)
+}
+
+// Exchange trades an authorization code for IdP tokens and fetches user info
+func (p *oauthProvider) Exchange(ctx context.Context, code string) (ExternalUserInfo, error) {
+	token, err := p.oauth2Conf.Exchange(ctx, code)
+	if err != nil {
+		return ExternalUserInfo{}, fmt.Errorf("failed to exchange %s authorization code: %w", p.name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return ExternalUserInfo{}, fmt.Errorf("failed to build %s userinfo request: %w", p.name, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := p.oauth2Conf.Client(ctx, token).Do(req)
+	if err != nil {
+		return ExternalUserInfo{}, fmt.Errorf("failed to fetch %s userinfo: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ExternalUserInfo{}, fmt.Errorf("failed to read %s userinfo response: %w", p.name, err)
+	}
+
+	var info struct {
+		Sub   string `json:"sub"`
+		Email string `json:"email"`
+	}
+	if err = json.Unmarshal(body, &info); err != nil {
+		return ExternalUserInfo{}, fmt.Errorf("failed to unmarshal %s userinfo response: %w", p.name, err)
+	}
+
+	return ExternalUserInfo{Sub: info.Sub, Login: info.Email}, nil
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// LoginProvider authenticates a user by username/password credentials
+type LoginProvider interface {
+	GenerateHashFromPassword(password string) (string, error)
+	CheckPasswordHash(password, hash string) bool
+}
+
+// OAuthProvider drives the OAuth2 authorization-code flow for a single IdP
+type OAuthProvider interface {
+	// AuthCodeURL returns the IdP redirect URL for the given opaque state
+	AuthCodeURL(state string) string
+	// Exchange trades an authorization code for IdP tokens and fetches user info
+	Exchange(ctx context.Context, code string) (ExternalUserInfo, error)
+}
+
+// ExternalUserInfo is the normalized subset of claims returned by an IdP's userinfo endpoint
+type ExternalUserInfo struct {
+	Sub   string
+	Login string
+}
+
+// StateStore persists short-lived OAuth state tokens so the callback can validate the IdP redirect
+type StateStore interface {
+	// Put stores state for the given ttl
+	Put(state string, ttl time.Duration)
+	// Consume returns true if state exists and has not expired, removing it either way
+	Consume(state string) bool
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+const stateCleanupInterval = time.Minute
+
+// memoryStateStore is the default in-process StateStore implementation
+type memoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]time.Time
+}
+
+// NewMemoryStateStore creates a StateStore that keeps OAuth state tokens in memory
+func NewMemoryStateStore() StateStore {
+	s := &memoryStateStore{states: make(map[string]time.Time)}
+	go s.cleanupLoop()
+	return s
+}
+
+// Put stores state for the given ttl
+func (s *memoryStateStore) Put(state string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state] = time.Now().Add(ttl)
+}
+
+// Consume returns true if state exists and has not expired, removing it either way
+func (s *memoryStateStore) Consume(state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.states[state]
+	delete(s.states, state)
+	if !ok {
+		return false
+	}
+
+	return time.Now().Before(expiresAt)
+}
+
+// cleanupLoop periodically evicts expired state tokens
+func (s *memoryStateStore) cleanupLoop() {
+	ticker := time.NewTicker(stateCleanupInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.mu.Lock()
+		for state, expiresAt := range s.states {
+			if now.After(expiresAt) {
+				delete(s.states, state)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
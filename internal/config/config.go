@@ -9,10 +9,12 @@ import (
 	"time"
 
 	authconf "github.com/ar4ie13/loyaltysystem/internal/auth/config"
+	grpcconf "github.com/ar4ie13/loyaltysystem/internal/grpc/config"
 	serverconf "github.com/ar4ie13/loyaltysystem/internal/handlers/config"
 	logconf "github.com/ar4ie13/loyaltysystem/internal/logger/config"
 	pgconf "github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql/config"
 	reqconf "github.com/ar4ie13/loyaltysystem/internal/requestor/config"
+	webhooksconf "github.com/ar4ie13/loyaltysystem/internal/webhooks/config"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -24,15 +26,24 @@ type Config struct {
 	PGConf      pgconf.PGConf
 	AccrualConf reqconf.ReqConf
 	LogConf     logconf.LogLevel
+	WebhookConf webhooksconf.WebhooksConf
+	GRPCConf    grpcconf.GRPCConf
+
+	// googleOAuth collects the -oauth-google-* flags / OAUTH_GOOGLE_* env vars; NewConfig wires it
+	// into AuthConf.OAuthProviders["google"] once both are set, so the google login route has
+	// somewhere to actually look up credentials instead of always failing with UNKNOWN_OAUTH_PROVIDER
+	googleOAuth authconf.OAuthProviderConfig
 }
 
 // NewConfig creates new Config configuration object
 func NewConfig() *Config {
 	c := &Config{
 		AuthConf: authconf.Config{
-			SecretKey:       "nHhjHgahbioHBGbBHJ",
-			TokenExpiration: 24 * time.Hour,
-			PasswordLen:     6,
+			SecretKey:              "nHhjHgahbioHBGbBHJ",
+			AccessTokenExpiration:  15 * time.Minute,
+			TokenExpiration:        24 * time.Hour,
+			RefreshTokenExpiration: 30 * 24 * time.Hour,
+			PasswordLen:            6,
 		},
 		ServerConf: serverconf.ServerConf{
 			ServerAddr: "localhost:8080",
@@ -44,12 +55,39 @@ func NewConfig() *Config {
 		LogConf: logconf.LogLevel{
 			Level: zerolog.DebugLevel,
 		},
+		WebhookConf: webhooksconf.WebhooksConf{
+			WorkerNum:      runtime.NumCPU(),
+			MaxAttempts:    8,
+			BaseBackoff:    1 * time.Second,
+			MaxBackoff:     5 * time.Minute,
+			RequestTimeout: 10 * time.Second,
+		},
+		GRPCConf: grpcconf.GRPCConf{
+			GRPCAddr: "localhost:3000",
+		},
 	}
 
 	c.BindFlags()
 	flag.Parse()
 	c.LoadEnv()
 
+	// Deprecated: -e / TOKEN_EXPIRATION historically set the access token lifetime; honor it
+	// for callers who haven't migrated to -t / ACCESS_TOKEN_EXPIRATION yet.
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "e" {
+			c.AuthConf.AccessTokenExpiration = c.AuthConf.TokenExpiration
+		}
+	})
+
+	// Google is only registered once both halves of the credential pair are configured; with
+	// neither set, OAuthProviders stays empty and the google login route 404s as before.
+	if c.googleOAuth.ClientID != "" && c.googleOAuth.ClientSecret != "" {
+		c.googleOAuth.AuthURL = "https://accounts.google.com/o/oauth2/v2/auth"
+		c.googleOAuth.TokenURL = "https://oauth2.googleapis.com/token"
+		c.googleOAuth.UserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+		c.AuthConf.OAuthProviders = map[string]authconf.OAuthProviderConfig{"google": c.googleOAuth}
+	}
+
 	return c
 }
 
@@ -58,10 +96,15 @@ func (c *Config) BindFlags() {
 	flag.StringVar(&c.ServerConf.ServerAddr, "a", c.ServerConf.ServerAddr, "server startup address (host:port)")
 	flag.StringVar(&c.PGConf.DatabaseDSN, "d", c.PGConf.DatabaseDSN, "database connection string")
 	flag.StringVar(&c.AccrualConf.AccrualAddr, "r", c.AccrualConf.AccrualAddr, "accrual server address")
+	flag.StringVar(&c.GRPCConf.GRPCAddr, "g", c.GRPCConf.GRPCAddr, "grpc server startup address (host:port)")
 	flag.Var(&c.LogConf, "l", "log level (debug, info, warn, error, fatal)")
 	flag.StringVar(&c.AuthConf.SecretKey, "k", c.AuthConf.SecretKey, "secret key for authorization")
-	flag.DurationVar(&c.AuthConf.TokenExpiration, "e", c.AuthConf.TokenExpiration, "token expiration")
+	flag.DurationVar(&c.AuthConf.AccessTokenExpiration, "t", c.AuthConf.AccessTokenExpiration, "access token expiration")
+	flag.DurationVar(&c.AuthConf.TokenExpiration, "e", c.AuthConf.TokenExpiration, "token expiration (deprecated, use -t)")
 	flag.IntVar(&c.AuthConf.PasswordLen, "p", c.AuthConf.PasswordLen, "password minimal length")
+	flag.StringVar(&c.googleOAuth.ClientID, "oauth-google-client-id", c.googleOAuth.ClientID, "Google OAuth2 client ID (enables Google login when set along with the client secret)")
+	flag.StringVar(&c.googleOAuth.ClientSecret, "oauth-google-client-secret", c.googleOAuth.ClientSecret, "Google OAuth2 client secret")
+	flag.StringVar(&c.googleOAuth.RedirectURL, "oauth-google-redirect-url", c.googleOAuth.RedirectURL, "Google OAuth2 redirect URL")
 }
 
 func (c *Config) LoadEnv() {
@@ -79,6 +122,10 @@ func (c *Config) LoadEnv() {
 		c.PGConf.DatabaseDSN = databaseDSN
 	}
 
+	if grpcAddr := os.Getenv("GRPC_ADDRESS"); grpcAddr != "" {
+		c.GRPCConf.GRPCAddr = grpcAddr
+	}
+
 	if accrualAddr := os.Getenv("ACCRUAL_SYSTEM_ADDRESS"); accrualAddr != "" {
 		if _, err := strconv.Unquote("\"" + accrualAddr + "\""); err != nil {
 			parts := strings.SplitN(accrualAddr, ":", 2)
@@ -100,13 +147,22 @@ func (c *Config) LoadEnv() {
 		c.AuthConf.SecretKey = secretKey
 	}
 
+	if accessTokenExpirationStr := os.Getenv("ACCESS_TOKEN_EXPIRATION"); accessTokenExpirationStr != "" {
+		var err error
+		c.AuthConf.AccessTokenExpiration, err = time.ParseDuration(accessTokenExpirationStr)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot parse access token expiration environment variable")
+		}
+	}
+
+	// Deprecated: TOKEN_EXPIRATION is the old name for ACCESS_TOKEN_EXPIRATION, kept for back-compat.
 	if tokenExpirationStr := os.Getenv("TOKEN_EXPIRATION"); tokenExpirationStr != "" {
 		var err error
 		c.AuthConf.TokenExpiration, err = time.ParseDuration(tokenExpirationStr)
 		if err != nil {
 			log.Fatal().Err(err).Msg("cannot parse token expiration environment variable")
 		}
-
+		c.AuthConf.AccessTokenExpiration = c.AuthConf.TokenExpiration
 	}
 
 	if passwordLen, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); passwordLen != 0 {
@@ -115,4 +171,16 @@ func (c *Config) LoadEnv() {
 		}
 		c.AuthConf.PasswordLen = passwordLen
 	}
+
+	if clientID := os.Getenv("OAUTH_GOOGLE_CLIENT_ID"); clientID != "" {
+		c.googleOAuth.ClientID = clientID
+	}
+
+	if clientSecret := os.Getenv("OAUTH_GOOGLE_CLIENT_SECRET"); clientSecret != "" {
+		c.googleOAuth.ClientSecret = clientSecret
+	}
+
+	if redirectURL := os.Getenv("OAUTH_GOOGLE_REDIRECT_URL"); redirectURL != "" {
+		c.googleOAuth.RedirectURL = redirectURL
+	}
 }
@@ -0,0 +1,6 @@
+package config
+
+// GRPCConf configures the gRPC server started alongside the REST API
+type GRPCConf struct {
+	GRPCAddr string
+}
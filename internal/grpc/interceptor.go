@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/grpc/config"
+	"github.com/ar4ie13/loyaltysystem/internal/grpc/pb"
+	"github.com/ar4ie13/loyaltysystem/internal/ratelimit"
+	"github.com/ar4ie13/loyaltysystem/internal/revocationcache"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// revocationCacheTTL mirrors handlers.revocationCacheTTL: how long a revocation check result is
+// trusted before the interceptor re-checks the session store
+const revocationCacheTTL = 5 * time.Second
+
+// unauthenticatedMethods lists the full RPC names that are reachable without a caller identity
+var unauthenticatedMethods = map[string]bool{
+	pb.LoyaltyService_Register_FullMethodName: true,
+	pb.LoyaltyService_Login_FullMethodName:    true,
+}
+
+// userUUIDCtxKey is an unexported type to avoid collisions with context keys from other packages
+type userUUIDCtxKey struct{}
+
+// userUUIDFromContext returns the caller's UUID placed by authUnaryInterceptor/authStreamInterceptor
+func userUUIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	userUUID, ok := ctx.Value(userUUIDCtxKey{}).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "missing caller identity")
+	}
+	return userUUID, nil
+}
+
+// authenticate extracts and validates the JWT carried in the "authorization" metadata entry,
+// rejecting it if its jti has been revoked, and returns a context carrying the caller's UUID
+func authenticate(ctx context.Context, auth Auth, srv Service, cache revocationcache.Cache) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+	tokenString := md.Get("authorization")[0]
+
+	userUUID, err := auth.ValidateUserUUID(tokenString)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+
+	jti, err := auth.ParseJTI(tokenString)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+
+	revoked, cached := cache.Get(jti)
+	if !cached {
+		revoked, err = srv.IsTokenRevoked(ctx, jti)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to check token revocation")
+		}
+		cache.Set(jti, revoked, revocationCacheTTL)
+	}
+	if revoked {
+		return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+	}
+
+	return context.WithValue(ctx, userUUIDCtxKey{}, userUUID), nil
+}
+
+// authUnaryInterceptor rejects unary calls without a valid, unrevoked access token, except
+// Register and Login
+func authUnaryInterceptor(auth Auth, srv Service, cache revocationcache.Cache) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if unauthenticatedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		authed, err := authenticate(ctx, auth, srv, cache)
+		if err != nil {
+			return nil, err
+		}
+		return handler(authed, req)
+	}
+}
+
+// authStreamInterceptor applies the same rule as authUnaryInterceptor to streaming RPCs
+func authStreamInterceptor(auth Auth, srv Service, cache revocationcache.Cache) grpc.StreamServerInterceptor {
+	return func(srv2 interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if unauthenticatedMethods[info.FullMethod] {
+			return handler(srv2, ss)
+		}
+
+		authed, err := authenticate(ss.Context(), auth, srv, cache)
+		if err != nil {
+			return err
+		}
+		return handler(srv2, &authedServerStream{ServerStream: ss, ctx: authed})
+	}
+}
+
+// authedServerStream overrides Context so handlers observe the context carrying the caller's UUID
+type authedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// errorUnaryInterceptor maps any *apperrors.AppError returned by a handler to the matching gRPC
+// status code, so callers get the same failure classification the REST error envelope carries
+func errorUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		return resp, toStatusError(err)
+	}
+}
+
+// errorStreamInterceptor is errorUnaryInterceptor's streaming-RPC equivalent
+func errorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return toStatusError(err)
+		}
+		return nil
+	}
+}
+
+// toStatusError maps apperrors.AppError codes to gRPC status codes, falling back to Internal
+// for errors the mapping doesn't know about (already-gRPC statuses pass through untouched)
+func toStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	var code codes.Code
+	switch appErr.Code {
+	case apperrors.ErrUserAlreadyExists.Code, apperrors.ErrOrderNumberAlreadyUsed.Code:
+		code = codes.AlreadyExists
+	case apperrors.ErrBalanceNotEnough.Code, apperrors.ErrWithdrawnNotPositive.Code, apperrors.ErrIncorrectOrderNumber.Code:
+		code = codes.FailedPrecondition
+	case apperrors.ErrNoOrders.Code, apperrors.ErrUserNotFound.Code, apperrors.ErrWebhookNotFound.Code:
+		code = codes.NotFound
+	case apperrors.ErrForbidden.Code:
+		code = codes.PermissionDenied
+	case apperrors.ErrUserIsNotAuthorized.Code, apperrors.ErrInvalidPassword.Code:
+		code = codes.Unauthenticated
+	case apperrors.ErrBadRequest.Code, apperrors.ErrInvalidLoginString.Code, apperrors.ErrInvalidUserUUID.Code, apperrors.ErrPasswordMinSymbols.Code:
+		code = codes.InvalidArgument
+	case apperrors.ErrRateLimited.Code:
+		code = codes.ResourceExhausted
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, appErr.Message)
+}
+
+// NewServer builds the gRPC server exposing LoyaltyService on top of srv and auth, the same
+// service.Service and auth.Auth instances the REST handlers use. Revocation checks are cached
+// in-process, mirroring handlers.Handlers' authMiddleware.
+func NewServer(auth Auth, srv Service) *grpc.Server {
+	cache := revocationcache.NewMemoryCache()
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authUnaryInterceptor(auth, srv, cache), errorUnaryInterceptor()),
+		grpc.ChainStreamInterceptor(authStreamInterceptor(auth, srv, cache), errorStreamInterceptor()),
+	)
+	pb.RegisterLoyaltyServiceServer(s, &server{
+		auth:          auth,
+		srv:           srv,
+		rlStore:       ratelimit.NewMemoryStore(),
+		loginAttempts: ratelimit.NewMemoryLoginAttemptTracker(),
+	})
+	return s
+}
+
+// ListenAndServe starts the gRPC server on cfg.GRPCAddr, blocking until it stops or fails
+func ListenAndServe(cfg config.GRPCConf, zlog zerolog.Logger, auth Auth, srv Service) error {
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		return err
+	}
+
+	zlog.Info().Msgf("grpc listening on %v", cfg.GRPCAddr)
+	return NewServer(auth, srv).Serve(lis)
+}
@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/grpc/pb"
+	"github.com/ar4ie13/loyaltysystem/internal/models"
+	"github.com/ar4ie13/loyaltysystem/internal/ratelimit"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// loginRateLimit mirrors handlers.loginRateLimit: Register/Login are throttled much more tightly
+// than the already-authenticated RPCs, which go through authUnaryInterceptor instead
+var loginRateLimit = ratelimit.Limit{Rate: 5, Interval: time.Minute, Burst: 5}
+
+// Auth is the subset of auth.Auth the gRPC server needs to mint and validate access tokens,
+// mirroring handlers.Auth
+type Auth interface {
+	BuildJWTString(userUUID uuid.UUID, userRole role.Role) (string, error)
+	ValidateUserUUID(tokenString string) (uuid.UUID, error)
+	ParseJTI(tokenString string) (string, error)
+	GenerateHashFromPassword(password string) (string, error)
+	CheckPasswordHash(password, hash string) bool
+}
+
+// Service is the subset of service.Service the gRPC server needs, mirroring handlers.Service
+type Service interface {
+	CreateUser(ctx context.Context, user models.User) error
+	LoginUser(ctx context.Context, login string) (models.User, error)
+	PutUserOrder(ctx context.Context, user uuid.UUID, order string) error
+	GetUserOrders(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error)
+	GetBalance(ctx context.Context, user uuid.UUID) (models.User, error)
+	PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNum string, withdrawn float64) error
+	GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error)
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// server implements pb.LoyaltyServiceServer on top of the same service.Service and auth.Auth the
+// REST handlers use, so business logic lives in exactly one place. rlStore/loginAttempts mirror
+// handlers.Handlers' rate limiting so Register/Login can't be brute-forced through this surface
+// just because the REST one is throttled.
+type server struct {
+	pb.UnimplementedLoyaltyServiceServer
+	auth          Auth
+	srv           Service
+	rlStore       ratelimit.Store
+	loginAttempts ratelimit.LoginAttemptTracker
+}
+
+// peerKey returns the caller's address, used to key the Register/Login rate limit
+func peerKey(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+// Register hashes the password and creates the user the same way userRegister does, returning a
+// freshly minted access token
+func (s *server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.SessionResponse, error) {
+	if allowed, retryAfter := s.rlStore.Allow(peerKey(ctx), loginRateLimit); !allowed {
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limited, retry after %s", retryAfter)
+	}
+
+	passwordHash, err := s.auth.GenerateHashFromPassword(req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{Login: req.GetLogin(), PasswordHash: passwordHash}
+	if err = s.srv.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// CreateUser assigns the UUID and role on its own copy, so read the stored user back
+	if user, err = s.srv.LoginUser(ctx, req.GetLogin()); err != nil {
+		return nil, err
+	}
+
+	return s.issueSession(user.UUID, user.Role)
+}
+
+// Login checks the password and returns a freshly minted access token, the same way userLogin does
+func (s *server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.SessionResponse, error) {
+	if allowed, retryAfter := s.rlStore.Allow(peerKey(ctx), loginRateLimit); !allowed {
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limited, retry after %s", retryAfter)
+	}
+
+	if locked := s.loginAttempts.LockedFor(req.GetLogin()); locked > 0 {
+		return nil, status.Errorf(codes.ResourceExhausted, "account locked, retry after %s", locked)
+	}
+
+	user, err := s.srv.LoginUser(ctx, req.GetLogin())
+	if err != nil {
+		s.loginAttempts.RecordFailure(req.GetLogin())
+		return nil, apperrors.ErrUserIsNotAuthorized.WithErr(err)
+	}
+
+	if !s.auth.CheckPasswordHash(req.GetPassword(), user.PasswordHash) {
+		s.loginAttempts.RecordFailure(req.GetLogin())
+		return nil, apperrors.ErrInvalidPassword
+	}
+
+	s.loginAttempts.RecordSuccess(req.GetLogin())
+
+	return s.issueSession(user.UUID, user.Role)
+}
+
+// PutOrder registers an order for the caller authenticated by authUnaryInterceptor
+func (s *server) PutOrder(ctx context.Context, req *pb.PutOrderRequest) (*pb.PutOrderResponse, error) {
+	userUUID, err := userUUIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.srv.PutUserOrder(ctx, userUUID, req.GetOrderNumber()); err != nil {
+		return nil, err
+	}
+
+	return &pb.PutOrderResponse{OrderNumber: req.GetOrderNumber()}, nil
+}
+
+// ListOrders streams every order belonging to the caller, newest first
+func (s *server) ListOrders(_ *pb.ListOrdersRequest, stream pb.LoyaltyService_ListOrdersServer) error {
+	userUUID, err := userUUIDFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.srv.GetUserOrders(stream.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		var accrual float64
+		if order.Accrual != nil {
+			accrual = *order.Accrual / 100.0
+		}
+		if err = stream.Send(&pb.Order{
+			Number:     order.OrderNumber,
+			Status:     order.Status,
+			Accrual:    accrual,
+			HasAccrual: order.Accrual != nil,
+			UploadedAt: order.CreatedAt.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetBalance returns the caller's current balance and total withdrawn
+func (s *server) GetBalance(ctx context.Context, _ *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
+	userUUID, err := userUUIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.srv.GetBalance(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetBalanceResponse{
+		Balance:   balance.Balance / 100,
+		Withdrawn: balance.Withdrawn / 100,
+	}, nil
+}
+
+// Withdraw debits the caller's balance against an order, the same way postOrderWithWithdrawn does
+func (s *server) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
+	userUUID, err := userUUIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.srv.PutUserWithdrawnOrder(ctx, userUUID, req.GetOrderNumber(), req.GetSum()); err != nil {
+		return nil, err
+	}
+
+	return &pb.WithdrawResponse{OrderNumber: req.GetOrderNumber()}, nil
+}
+
+// ListWithdrawals streams every withdrawal made by the caller, newest first
+func (s *server) ListWithdrawals(_ *pb.ListWithdrawalsRequest, stream pb.LoyaltyService_ListWithdrawalsServer) error {
+	userUUID, err := userUUIDFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.srv.GetUserWithdrawals(stream.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		var sum float64
+		if order.Withdrawn != nil {
+			sum = *order.Withdrawn / 100
+		}
+		if err = stream.Send(&pb.Withdrawal{
+			OrderNumber: order.OrderNumber,
+			Sum:         sum,
+			ProcessedAt: order.CreatedAt.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// issueSession mints an access token for userUUID, mirroring handlers.issueSession minus the
+// refresh-token cookie, which has no gRPC equivalent in this surface
+func (s *server) issueSession(userUUID uuid.UUID, userRole role.Role) (*pb.SessionResponse, error) {
+	accessToken, err := s.auth.BuildJWTString(userUUID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SessionResponse{AccessToken: accessToken}, nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// getTargetUUIDFromParam is a helper that parses the ":uuid" path param admin routes act upon
+func (h *Handlers) getTargetUUIDFromParam(c *gin.Context) (uuid.UUID, error) {
+	return uuid.Parse(c.Param("uuid"))
+}
+
+// adminListUsers is a handler that returns every registered user
+func (h *Handlers) adminListUsers(c *gin.Context) error {
+	users, err := h.srv.ListUsers(c)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, users)
+	return nil
+}
+
+// adminGetUserOrders is a handler that returns a target user's orders
+func (h *Handlers) adminGetUserOrders(c *gin.Context) error {
+	targetUUID, err := h.getTargetUUIDFromParam(c)
+	if err != nil {
+		return apperrors.ErrInvalidUserUUID.WithErr(err)
+	}
+
+	orders, err := h.srv.GetUserOrders(c, targetUUID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, orders)
+	return nil
+}
+
+// adminGetUserBalance is a handler that returns a target user's balance
+func (h *Handlers) adminGetUserBalance(c *gin.Context) error {
+	targetUUID, err := h.getTargetUUIDFromParam(c)
+	if err != nil {
+		return apperrors.ErrInvalidUserUUID.WithErr(err)
+	}
+
+	balance, err := h.srv.GetBalance(c, targetUUID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, balance)
+	return nil
+}
+
+// adminGetUserWithdrawals is a handler that returns a target user's withdrawals
+func (h *Handlers) adminGetUserWithdrawals(c *gin.Context) error {
+	targetUUID, err := h.getTargetUUIDFromParam(c)
+	if err != nil {
+		return apperrors.ErrInvalidUserUUID.WithErr(err)
+	}
+
+	orders, err := h.srv.GetUserWithdrawals(c, targetUUID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, orders)
+	return nil
+}
+
+// adminAdjustBalance is a handler that applies a manual balance adjustment to a target user's account
+func (h *Handlers) adminAdjustBalance(c *gin.Context) error {
+	actorUUID, err := h.getUserUUIDFromRequest(c)
+	if err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	targetUUID, err := h.getTargetUUIDFromParam(c)
+	if err != nil {
+		return apperrors.ErrInvalidUserUUID.WithErr(err)
+	}
+
+	var adjustReq adminAdjustBalanceRequest
+	if err = c.ShouldBindJSON(&adjustReq); err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	if err = h.srv.AdminAdjustBalance(c, actorUUID, targetUUID, adjustReq.Delta, adjustReq.Reason); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "balance adjusted"})
+	return nil
+}
+
+// adminRevokeSessions is a handler that revokes every active session belonging to a target user
+func (h *Handlers) adminRevokeSessions(c *gin.Context) error {
+	actorUUID, err := h.getUserUUIDFromRequest(c)
+	if err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	targetUUID, err := h.getTargetUUIDFromParam(c)
+	if err != nil {
+		return apperrors.ErrInvalidUserUUID.WithErr(err)
+	}
+
+	if err = h.srv.AdminRevokeSessions(c, actorUUID, targetUUID); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked"})
+	return nil
+}
+
+// adminGetAuditLog is a handler that returns the most recent administrative actions
+func (h *Handlers) adminGetAuditLog(c *gin.Context) error {
+	const auditLogLimit = 100
+
+	entries, err := h.srv.GetAuditLog(c, auditLogLimit)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, entries)
+	return nil
+}
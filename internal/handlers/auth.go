@@ -1,13 +1,18 @@
 package handlers
 
 import (
-	"net/http"
+	"time"
 
 	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// revocationCacheTTL bounds how long a revocation check result is trusted before the middleware
+// re-checks the session store, trading a short detection delay for one fewer DB hit per request
+const revocationCacheTTL = 5 * time.Second
+
 // authMiddleware used as middleware for authentication
 func (h *Handlers) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -20,16 +25,51 @@ func (h *Handlers) authMiddleware() gin.HandlerFunc {
 
 		if err != nil || cookie == "" {
 			h.zlog.Debug().Msg("user is not authorized")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": apperrors.ErrUserIsNotAuthorized.Error()})
+			h.respondError(c, apperrors.ErrUserIsNotAuthorized)
 			return
 		} else {
 			// Checking existing cookie
 			userUUID, err = h.auth.ValidateUserUUID(cookie)
 			if err != nil {
 				h.zlog.Debug().Msgf("error validating user UUID: %v", err)
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid cookie"})
+				h.respondError(c, apperrors.ErrUserIsNotAuthorized.WithErr(err))
+				return
+			}
+
+			jti, err := h.auth.ParseJTI(cookie)
+			if err != nil {
+				h.zlog.Debug().Msgf("error parsing token jti: %v", err)
+				h.respondError(c, apperrors.ErrUserIsNotAuthorized.WithErr(err))
+				return
+			}
+
+			revoked, cached := h.revocationCache.Get(jti)
+			if !cached {
+				revoked, err = h.srv.IsTokenRevoked(c, jti)
+				if err != nil {
+					h.zlog.Error().Msgf("error checking token revocation: %v", err)
+					h.respondError(c, apperrors.ErrInternal)
+					return
+				}
+				h.revocationCache.Set(jti, revoked, revocationCacheTTL)
+			}
+			if revoked {
+				h.zlog.Debug().Msg("token has been revoked")
+				h.respondError(c, apperrors.ErrUserIsNotAuthorized)
 				return
 			}
+
+			userRole, err := h.auth.ParseRole(cookie)
+			if err != nil {
+				h.zlog.Debug().Msgf("error parsing token role: %v", err)
+				h.respondError(c, apperrors.ErrUserIsNotAuthorized.WithErr(err))
+				return
+			}
+
+			// Carry the caller's role on the request context so service-layer methods can enforce
+			// it themselves, regardless of which handler/middleware believes the caller is allowed in
+			c.Request = c.Request.WithContext(role.WithContext(c.Request.Context(), userRole))
+			c.Set("role", userRole)
 		}
 		// Set user UUID in the context for downstream handlers
 		c.Set("user_uuid", userUUID.String())
@@ -27,3 +27,21 @@ type orderWithWithdrawn struct {
 	Sum         float64 `json:"sum"`
 	ProcessedAt string  `json:"processed_at"`
 }
+
+type adminAdjustBalanceRequest struct {
+	Delta  float64 `json:"delta"`
+	Reason string  `json:"reason"`
+}
+
+type webhookSubscriptionRequest struct {
+	URL string `json:"url"`
+}
+
+// webhookSubscriptionResponse carries the subscription's signing secret, which is only ever
+// returned once, right after creation
+type webhookSubscriptionResponse struct {
+	ID        int64  `json:"id"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+	requestIDKey    = "request_id"
+)
+
+// errorEnvelope is the stable, machine-parseable error response shape returned by the API
+type errorEnvelope struct {
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	RequestID string         `json:"request_id"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// HandlerFunc is the signature used by routes registered through wrap — handlers report failure by
+// returning an error instead of writing the response themselves
+type HandlerFunc func(c *gin.Context) error
+
+// wrap adapts a HandlerFunc to gin.HandlerFunc, translating any returned error into the structured
+// error envelope
+func (h *Handlers) wrap(fn HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := fn(c); err != nil {
+			h.respondError(c, err)
+		}
+	}
+}
+
+// respondError walks err for an *apperrors.AppError and writes its structured envelope, falling
+// back to a generic 500 INTERNAL envelope for errors the API doesn't know how to classify
+func (h *Handlers) respondError(c *gin.Context, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		h.zlog.Error().Msgf("unhandled error: %v", err)
+		appErr = apperrors.ErrInternal
+	}
+
+	env := errorEnvelope{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		RequestID: requestIDFromContext(c),
+	}
+	if appErr.Err != nil {
+		env.Details = map[string]any{"error": appErr.Err.Error()}
+	}
+
+	c.AbortWithStatusJSON(appErr.HTTPStatus, env)
+}
+
+// requestIDMiddleware tags every request with a unique id, echoed in both the response header and
+// the error envelope, used to correlate client reports with server logs
+func (h *Handlers) requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := uuid.NewString()
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// requestIDFromContext returns the request id tagged by requestIDMiddleware, or "" if absent
+func requestIDFromContext(c *gin.Context) string {
+	id, ok := c.Get(requestIDKey)
+	if !ok {
+		return ""
+	}
+	return id.(string)
+}
+
+// recoveryMiddleware recovers panics from downstream handlers into the structured error envelope,
+// replacing gin's default plain-text recovery
+func (h *Handlers) recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				h.zlog.Error().Msgf("panic recovered: %v", r)
+				h.respondError(c, apperrors.ErrInternal)
+			}
+		}()
+		c.Next()
+	}
+}
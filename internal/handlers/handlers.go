@@ -4,50 +4,68 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/auth"
 	"github.com/ar4ie13/loyaltysystem/internal/handlers/config"
+	"github.com/ar4ie13/loyaltysystem/internal/metrics"
 	"github.com/ar4ie13/loyaltysystem/internal/models"
+	"github.com/ar4ie13/loyaltysystem/internal/ratelimit"
+	"github.com/ar4ie13/loyaltysystem/internal/revocationcache"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
-var errorStatusMap = map[error]int{
-	apperrors.ErrBalanceNotEnough:       http.StatusPaymentRequired,
-	apperrors.ErrUserAlreadyExists:      http.StatusConflict,
-	apperrors.ErrNoOrders:               http.StatusNoContent,
-	apperrors.ErrPasswordMinSymbols:     http.StatusBadRequest,
-	apperrors.ErrOrderAlreadyExists:     http.StatusOK,
-	apperrors.ErrIncorrectOrderNumber:   http.StatusUnprocessableEntity,
-	apperrors.ErrOrderNumberAlreadyUsed: http.StatusConflict,
-}
+// loginRateLimit and orderRateLimit are the per-route token-bucket limits applied in newRouter:
+// login/register is throttled much more tightly than the already-authenticated order endpoints
+var (
+	loginRateLimit = ratelimit.Limit{Rate: 5, Interval: time.Minute, Burst: 5}
+	orderRateLimit = ratelimit.Limit{Rate: 100, Interval: time.Minute, Burst: 20}
+)
 
 // Handlers is a main object for handlers layer
 type Handlers struct {
-	cfg  config.ServerConf
-	auth Auth
-	srv  Service
-	zlog zerolog.Logger
+	cfg             config.ServerConf
+	auth            Auth
+	srv             Service
+	zlog            zerolog.Logger
+	rlStore         ratelimit.Store
+	loginAttempts   ratelimit.LoginAttemptTracker
+	metrics         *metrics.Collector
+	revocationCache revocationcache.Cache
 }
 
 // NewHandlers creates Handler object
-func NewHandlers(cfg config.ServerConf, auth Auth, srv Service, zlog zerolog.Logger) *Handlers {
+func NewHandlers(cfg config.ServerConf, auth Auth, srv Service, zlog zerolog.Logger, mtr *metrics.Collector) *Handlers {
 	return &Handlers{
-		cfg:  cfg,
-		auth: auth,
-		srv:  srv,
-		zlog: zlog,
+		cfg:             cfg,
+		auth:            auth,
+		srv:             srv,
+		zlog:            zlog,
+		rlStore:         ratelimit.NewMemoryStore(),
+		loginAttempts:   ratelimit.NewMemoryLoginAttemptTracker(),
+		metrics:         mtr,
+		revocationCache: revocationcache.NewMemoryCache(),
 	}
 }
 
 // Auth used for authentication
 type Auth interface {
-	BuildJWTString(userUUID uuid.UUID) (string, error)
+	BuildJWTString(userUUID uuid.UUID, userRole role.Role) (string, error)
 	ValidateUserUUID(tokenString string) (uuid.UUID, error)
 	GenerateHashFromPassword(password string) (string, error)
 	CheckPasswordHash(password, hash string) bool
+	OAuthProvider(name string) (auth.OAuthProvider, bool)
+	StateStore() auth.StateStore
+	ParseJTI(tokenString string) (string, error)
+	ParseRole(tokenString string) (role.Role, error)
+	TokenExpiresAt(tokenString string) (time.Time, error)
+	RefreshTokenExpiration() time.Duration
+	GenerateOpaqueToken() (string, error)
 }
 
 // Service interface used in handlers layer
@@ -59,6 +77,20 @@ type Service interface {
 	GetBalance(ctx context.Context, user uuid.UUID) (models.User, error)
 	PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNum string, withdrawn float64) error
 	GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error)
+	LoginOrCreateBySub(ctx context.Context, provider, sub, login string) (models.User, error)
+	IssueRefreshToken(ctx context.Context, userUUID uuid.UUID, rawToken, userAgent, ip string) (models.RefreshToken, error)
+	RefreshSession(ctx context.Context, presentedRaw, nextRaw, userAgent, ip string) (models.RefreshToken, error)
+	Logout(ctx context.Context, refreshRaw, accessJTI string, accessExpiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	GetUserRole(ctx context.Context, userUUID uuid.UUID) (role.Role, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	AdminAdjustBalance(ctx context.Context, actor, target uuid.UUID, delta float64, reason string) error
+	AdminRevokeSessions(ctx context.Context, actor, target uuid.UUID) error
+	GetAuditLog(ctx context.Context, limit int) ([]models.AuditLogEntry, error)
+	CreateWebhookSubscription(ctx context.Context, userUUID uuid.UUID, rawURL string) (models.WebhookSubscription, error)
+	AdminCreateWebhookSubscription(ctx context.Context, rawURL string) (models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, userUUID uuid.UUID) ([]models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, userUUID uuid.UUID, id int64) error
 }
 
 // ListenAndServe starts server
@@ -78,70 +110,86 @@ func (h *Handlers) ListenAndServe() error {
 func (h *Handlers) newRouter() *gin.Engine {
 	router := gin.New()
 
+	// Gin trusts every proxy (and thus a client-supplied X-Forwarded-For) by default. This
+	// deployment sits directly behind its clients with no reverse proxy in front of it, so disable
+	// that trust entirely rather than let ClientIP() return a spoofable header value; the rate
+	// limiter and login lockout key on ClientIP() and must not be bypassable by rotating it.
+	if err := router.SetTrustedProxies(nil); err != nil {
+		h.zlog.Error().Err(err).Msg("unable to disable trusted proxies")
+	}
+
 	//middlewares for router
+	router.Use(h.requestIDMiddleware())
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(h.recoveryMiddleware())
+	router.Use(h.metricsMiddleware())
+
+	router.GET("/metrics", gin.WrapH(h.metrics.Handler()))
 
 	//API routes
-	auth := router.Group("/api/user")
+	auth := router.Group("/api/user").Use(h.rateLimit(loginRateLimit))
 	{
-		auth.POST("/register", h.userRegister)
-		auth.POST("/login", h.userLogin)
+		auth.POST("/register", h.wrap(h.userRegister))
+		auth.POST("/login", h.wrap(h.userLogin))
 	}
-	user := router.Group("/api/user").Use(h.authMiddleware())
+
+	oauth := router.Group("/api/user/oauth").Use(h.rateLimit(loginRateLimit))
 	{
-		user.GET("/test", h.testAuth)
-		user.POST("/orders", h.postOrder)
-		user.GET("/balance", h.getUserBalance)
-		user.POST("/balance/withdraw", h.postOrderWithWithdrawn)
+		oauth.GET("/:provider/login", h.wrap(h.oauthLogin))
+		oauth.GET("/:provider/callback", h.wrap(h.oauthCallback))
 	}
 
-	userGzip := router.Group("/api/user").Use(h.authMiddleware()).Use(h.gzipMiddleware())
+	session := router.Group("/api/user").Use(h.rateLimit(loginRateLimit))
 	{
-		userGzip.GET("/orders", h.getUserOrders)
-		userGzip.GET("/withdrawals", h.getUserWithdrawals)
+		session.POST("/refresh", h.wrap(h.postRefresh))
+		session.POST("/logout", h.wrap(h.postLogout))
 	}
 
-	return router
-}
+	user := router.Group("/api/user").Use(h.authMiddleware()).Use(h.rateLimit(orderRateLimit))
+	{
+		user.GET("/test", h.wrap(h.testAuth))
+		user.POST("/orders", h.wrap(h.postOrder))
+		user.GET("/balance", h.wrap(h.getUserBalance))
+		user.POST("/balance/withdraw", h.wrap(h.postOrderWithWithdrawn))
+		user.POST("/webhooks", h.wrap(h.postWebhookSubscription))
+		user.GET("/webhooks", h.wrap(h.getWebhookSubscriptions))
+		user.DELETE("/webhooks/:id", h.wrap(h.deleteWebhookSubscription))
+	}
 
-// getStatusCode process error and return the correlated status code
-func (h *Handlers) getStatusCode(err error) int {
-	// fast error check
-	if status, exists := errorStatusMap[err]; exists {
-		return status
+	userGzip := router.Group("/api/user").Use(h.authMiddleware()).Use(h.rateLimit(orderRateLimit)).Use(h.gzipMiddleware())
+	{
+		userGzip.GET("/orders", h.wrap(h.getUserOrders))
+		userGzip.GET("/withdrawals", h.wrap(h.getUserWithdrawals))
 	}
 
-	// For wrapped errors
-	for errType, status := range errorStatusMap {
-		if errors.Is(err, errType) {
-			return status
-		}
+	admin := router.Group("/api/admin").Use(h.authMiddleware()).Use(h.requireRole(role.RoleAdmin, role.RoleSupport))
+	{
+		admin.GET("/users", h.wrap(h.adminListUsers))
+		admin.GET("/users/:uuid/orders", h.wrap(h.adminGetUserOrders))
+		admin.GET("/users/:uuid/balance", h.wrap(h.adminGetUserBalance))
+		admin.GET("/users/:uuid/withdrawals", h.wrap(h.adminGetUserWithdrawals))
+		admin.POST("/users/:uuid/balance/adjust", h.wrap(h.adminAdjustBalance))
+		admin.POST("/users/:uuid/revoke", h.wrap(h.adminRevokeSessions))
+		admin.GET("/audit-log", h.wrap(h.adminGetAuditLog))
+		admin.POST("/webhooks", h.wrap(h.adminPostWebhookSubscription))
 	}
-	return http.StatusInternalServerError
+
+	return router
 }
 
 // userRegister is a handler used for user registration by using provided login and password
-func (h *Handlers) userRegister(c *gin.Context) {
+func (h *Handlers) userRegister(c *gin.Context) error {
 	var registerReq registerRequest
 
 	// Bind JSON to struct
 	if err := c.ShouldBindJSON(&registerReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
 	// Process the register data
 	passwordHash, err := h.auth.GenerateHashFromPassword(registerReq.Password)
 	if err != nil {
-		c.JSON(h.getStatusCode(err), gin.H{
-			"error":   "cannot generate hash from password",
-			"details": err.Error(),
-		})
-		return
+		return err
 	}
 
 	user := models.User{
@@ -149,85 +197,77 @@ func (h *Handlers) userRegister(c *gin.Context) {
 		PasswordHash: passwordHash,
 	}
 
-	err = h.srv.CreateUser(c, user)
-	if err != nil {
-		c.JSON(h.getStatusCode(err), gin.H{
-			"error":   "cannot create user",
-			"details": err.Error(),
-		})
-		return
+	if err = h.srv.CreateUser(c, user); err != nil {
+		return err
 	}
 
-	tokenString, err := h.auth.BuildJWTString(user.UUID)
-	if err != nil {
-		h.zlog.Error().Msgf("error building JWT string: %v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// CreateUser assigns the UUID and role on its own copy, so read the stored user back
+	if user, err = h.srv.LoginUser(c, registerReq.Login); err != nil {
+		return err
+	}
+
+	if err = h.issueSession(c, user.UUID, user.Role); err != nil {
+		return err
 	}
-	c.SetCookie("user_uuid", tokenString, 0, "/", "", false, true)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "user successfully registered",
 		"login":   registerReq.Login,
 	})
+	return nil
 }
 
 // userLogin is a handler used for users logging in
-func (h *Handlers) userLogin(c *gin.Context) {
+func (h *Handlers) userLogin(c *gin.Context) error {
 	var loginReq loginRequest
 
 	// Bind JSON to struct
 	if err := c.ShouldBindJSON(&loginReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	// Reject outright while the login is locked out from prior failures
+	if locked := h.loginAttempts.LockedFor(loginReq.Login); locked > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(locked.Seconds())+1))
+		return apperrors.ErrRateLimited
 	}
+
 	// Process the login data
 	user, err := h.srv.LoginUser(c, loginReq.Login)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "cannot login",
-			"details": err.Error(),
-		})
-		return
+		h.loginAttempts.RecordFailure(loginReq.Login)
+		return apperrors.ErrUserIsNotAuthorized.WithErr(err)
 	}
 
 	if !h.auth.CheckPasswordHash(loginReq.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": apperrors.ErrInvalidPassword.Error(),
-		})
-		return
+		h.loginAttempts.RecordFailure(loginReq.Login)
+		return apperrors.ErrInvalidPassword
 	}
 
-	tokenString, err := h.auth.BuildJWTString(user.UUID)
-	if err != nil {
-		h.zlog.Error().Msgf("error building JWT string: %v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	h.loginAttempts.RecordSuccess(loginReq.Login)
+
+	if err = h.issueSession(c, user.UUID, user.Role); err != nil {
+		return err
 	}
-	c.SetCookie("user_uuid", tokenString, 0, "/", "", false, true)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "user successfully logged in",
 		"login":   loginReq.Login,
 	})
+	return nil
 }
 
 // testAuth used for testing authentication middleware
-func (h *Handlers) testAuth(c *gin.Context) {
+func (h *Handlers) testAuth(c *gin.Context) error {
 	userUUID, ok := c.Get("user_uuid")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "internal server error",
-		})
-		return
+		return apperrors.ErrInternal
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "user orders: void",
 		"user_uuid": userUUID,
 	})
+	return nil
 }
 
 // getUserUUIDFromRequest is a helper that retrieves user UUID from request
@@ -247,59 +287,39 @@ func (h *Handlers) getUserUUIDFromRequest(c *gin.Context) (uuid.UUID, error) {
 }
 
 // postOrder is a handler used for posting order provided by user in request without withdrawn
-func (h *Handlers) postOrder(c *gin.Context) {
+func (h *Handlers) postOrder(c *gin.Context) error {
 
 	userUUID, err := h.getUserUUIDFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
 	order, err := c.GetRawData()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "cannot get order",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
-	err = h.srv.PutUserOrder(c, userUUID, string(order))
-	if err != nil {
-		c.JSON(h.getStatusCode(err), gin.H{
-			"error":   "cannot register order",
-			"details": err.Error(),
-		})
-		return
+	if err = h.srv.PutUserOrder(c, userUUID, string(order)); err != nil {
+		return err
 	}
 
 	c.JSON(http.StatusAccepted, gin.H{
 		"message": "order successfully registered",
 		"order":   string(order),
 	})
+	return nil
 }
 
 // getUserOrders is a handler that returns all user's orders
-func (h *Handlers) getUserOrders(c *gin.Context) {
+func (h *Handlers) getUserOrders(c *gin.Context) error {
 	userUUID, err := h.getUserUUIDFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
 	orders, err := h.srv.GetUserOrders(c, userUUID)
 	if err != nil {
-		c.JSON(h.getStatusCode(err), gin.H{
-			"error":   "cannot get orders",
-			"details": err.Error(),
-		})
-		return
+		return err
 	}
 
 	var ordersResponse []userOrdersResponse
@@ -316,89 +336,63 @@ func (h *Handlers) getUserOrders(c *gin.Context) {
 		ordersResponse = append(ordersResponse, orderResponse)
 	}
 	c.JSON(http.StatusOK, ordersResponse)
+	return nil
 }
 
 // getUserBalance is a handler that return user's balance
-func (h *Handlers) getUserBalance(c *gin.Context) {
+func (h *Handlers) getUserBalance(c *gin.Context) error {
 	userUUID, err := h.getUserUUIDFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
 	balance, err := h.srv.GetBalance(c, userUUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "cannot get user balance",
-			"details": err.Error(),
-		})
-		return
+		return err
 	}
 
 	var userBal userBalance
 	userBal.Balance = float64(balance.Balance) / 100
 	userBal.Withdrawn = float64(balance.Withdrawn) / 100
 	c.JSON(http.StatusOK, userBal)
+	return nil
 }
 
 // postOrderWithWithdrawn is a handler that post order with withdrawn
-func (h *Handlers) postOrderWithWithdrawn(c *gin.Context) {
+func (h *Handlers) postOrderWithWithdrawn(c *gin.Context) error {
 
 	userUUID, err := h.getUserUUIDFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
 	// Bind JSON to struct
 	var orderWWithdrawn orderWithWithdrawn
 	if err = c.ShouldBindJSON(&orderWWithdrawn); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
-	err = h.srv.PutUserWithdrawnOrder(c, userUUID, orderWWithdrawn.Order, orderWWithdrawn.Sum)
-	if err != nil {
-		c.JSON(h.getStatusCode(err), gin.H{
-			"error":   "cannot register order",
-			"details": err.Error(),
-		})
-		return
+	if err = h.srv.PutUserWithdrawnOrder(c, userUUID, orderWWithdrawn.Order, orderWWithdrawn.Sum); err != nil {
+		return err
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "order successfully registered",
 		"order":   orderWWithdrawn.Order,
 	})
+	return nil
 }
 
 // getUserWithdrawals is a handler that returns all user's withdrawals
-func (h *Handlers) getUserWithdrawals(c *gin.Context) {
+func (h *Handlers) getUserWithdrawals(c *gin.Context) error {
 	userUUID, err := h.getUserUUIDFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
-		return
+		return apperrors.ErrBadRequest.WithErr(err)
 	}
 
 	orders, err := h.srv.GetUserWithdrawals(c, userUUID)
 	if err != nil {
-		c.JSON(h.getStatusCode(err), gin.H{
-			"error":   "cannot get user balance",
-			"details": err.Error(),
-		})
-		return
+		return err
 	}
 
 	var ordersResponse []orderWithWithdrawn
@@ -412,4 +406,5 @@ func (h *Handlers) getUserWithdrawals(c *gin.Context) {
 		ordersResponse = append(ordersResponse, orderResponse)
 	}
 	c.JSON(http.StatusOK, ordersResponse)
+	return nil
 }
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsMiddleware records every request's outcome and latency against h.metrics, labeled by the
+// route pattern rather than the raw path so per-id URLs don't blow up cardinality
+func (h *Handlers) metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		h.metrics.ObserveHTTPRequest(c.Request.Method, path, status, time.Since(start))
+	}
+}
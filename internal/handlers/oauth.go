@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	oauthStateTTL    = 5 * time.Minute
+	oauthStateCookie = "oauth_state"
+)
+
+// oauthLogin redirects the user to the IdP's authorization endpoint for the requested provider
+func (h *Handlers) oauthLogin(c *gin.Context) error {
+	providerName := c.Param("provider")
+
+	provider, ok := h.auth.OAuthProvider(providerName)
+	if !ok {
+		return &apperrors.AppError{Code: "UNKNOWN_OAUTH_PROVIDER", HTTPStatus: http.StatusNotFound, Message: "unknown oauth provider"}
+	}
+
+	state := uuid.New().String()
+	h.auth.StateStore().Put(state, oauthStateTTL)
+	c.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "/", "", false, true)
+
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state))
+	return nil
+}
+
+// oauthCallback validates the IdP redirect, exchanges the code for user info and logs the user in
+func (h *Handlers) oauthCallback(c *gin.Context) error {
+	providerName := c.Param("provider")
+
+	provider, ok := h.auth.OAuthProvider(providerName)
+	if !ok {
+		return &apperrors.AppError{Code: "UNKNOWN_OAUTH_PROVIDER", HTTPStatus: http.StatusNotFound, Message: "unknown oauth provider"}
+	}
+
+	stateCookie, err := c.Cookie(oauthStateCookie)
+	if err != nil || stateCookie == "" || stateCookie != c.Query("state") || !h.auth.StateStore().Consume(stateCookie) {
+		return &apperrors.AppError{Code: "INVALID_OAUTH_STATE", HTTPStatus: http.StatusUnauthorized, Message: "invalid or expired oauth state"}
+	}
+
+	info, err := provider.Exchange(c, c.Query("code"))
+	if err != nil {
+		h.zlog.Error().Msgf("error exchanging oauth code for provider %s: %v", providerName, err)
+		return &apperrors.AppError{Code: "OAUTH_EXCHANGE_FAILED", HTTPStatus: http.StatusBadGateway, Message: "cannot complete oauth login"}
+	}
+
+	user, err := h.srv.LoginOrCreateBySub(c, providerName, info.Sub, info.Login)
+	if err != nil {
+		return err
+	}
+
+	if err = h.issueSession(c, user.UUID, user.Role); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "user successfully logged in",
+		"provider": providerName,
+	})
+	return nil
+}
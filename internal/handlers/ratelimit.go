@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimit returns a middleware that throttles requests under limit using a token bucket, keyed
+// by the authenticated user_uuid when present and falling back to the caller's IP otherwise
+func (h *Handlers) rateLimit(limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.ClientIP()
+		if userUUID, ok := c.Get("user_uuid"); ok {
+			key = userUUID.(string)
+		}
+
+		allowed, retryAfter := h.rlStore.Allow(key, limit)
+		if !allowed {
+			h.zlog.Debug().Msgf("rate limit exceeded for %s", key)
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			h.respondError(c, apperrors.ErrRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
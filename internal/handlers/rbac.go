@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
+	"github.com/gin-gonic/gin"
+)
+
+// requireRole used as middleware to restrict a route group to callers holding one of the given
+// roles. Must run after authMiddleware, which is what populates the "role" context key.
+func (h *Handlers) requireRole(roles ...role.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		callerRole, ok := c.Get("role")
+		if !ok {
+			h.respondError(c, apperrors.ErrForbidden)
+			return
+		}
+
+		for _, r := range roles {
+			if callerRole == r {
+				c.Next()
+				return
+			}
+		}
+
+		h.zlog.Debug().Msgf("role %v is not allowed to access this resource", callerRole)
+		h.respondError(c, apperrors.ErrForbidden)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const refreshCookie = "refresh_token"
+
+// issueSession builds a new access token and its paired refresh token and sets both as cookies
+func (h *Handlers) issueSession(c *gin.Context, userUUID uuid.UUID, userRole role.Role) error {
+	tokenString, err := h.auth.BuildJWTString(userUUID, userRole)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := h.auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if _, err = h.srv.IssueRefreshToken(c, userUUID, refreshToken, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		return err
+	}
+
+	c.SetCookie("user_uuid", tokenString, 0, "/", "", false, true)
+	c.SetCookie(refreshCookie, refreshToken, int(h.auth.RefreshTokenExpiration().Seconds()), "/", "", false, true)
+
+	return nil
+}
+
+// postRefresh rotates the presented refresh token and issues a new access/refresh token pair
+func (h *Handlers) postRefresh(c *gin.Context) error {
+	presented, err := c.Cookie(refreshCookie)
+	if err != nil || presented == "" {
+		return apperrors.ErrUserIsNotAuthorized
+	}
+
+	nextRefresh, err := h.auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	rt, err := h.srv.RefreshSession(c, presented, nextRefresh, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return err
+	}
+
+	userRole, err := h.srv.GetUserRole(c, rt.UserUUID)
+	if err != nil {
+		return err
+	}
+
+	tokenString, err := h.auth.BuildJWTString(rt.UserUUID, userRole)
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie("user_uuid", tokenString, 0, "/", "", false, true)
+	c.SetCookie(refreshCookie, nextRefresh, int(h.auth.RefreshTokenExpiration().Seconds()), "/", "", false, true)
+
+	c.JSON(http.StatusOK, gin.H{"message": "session refreshed"})
+	return nil
+}
+
+// postLogout revokes the current refresh token and blacklists the current access token
+func (h *Handlers) postLogout(c *gin.Context) error {
+	accessCookie, err := c.Cookie("user_uuid")
+	if err != nil || accessCookie == "" {
+		return apperrors.ErrUserIsNotAuthorized
+	}
+
+	refreshCookieVal, _ := c.Cookie(refreshCookie)
+
+	accessJTI, err := h.auth.ParseJTI(accessCookie)
+	if err != nil {
+		return apperrors.ErrUserIsNotAuthorized.WithErr(err)
+	}
+
+	accessExpiresAt, err := h.auth.TokenExpiresAt(accessCookie)
+	if err != nil {
+		return apperrors.ErrUserIsNotAuthorized.WithErr(err)
+	}
+
+	if err = h.srv.Logout(c, refreshCookieVal, accessJTI, accessExpiresAt); err != nil {
+		return err
+	}
+
+	c.SetCookie("user_uuid", "", -1, "/", "", false, true)
+	c.SetCookie(refreshCookie, "", -1, "/", "", false, true)
+
+	c.JSON(http.StatusOK, gin.H{"message": "user successfully logged out"})
+	return nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// postWebhookSubscription is a handler that registers a callback URL receiving the caller's own
+// order and balance events
+func (h *Handlers) postWebhookSubscription(c *gin.Context) error {
+	userUUID, err := h.getUserUUIDFromRequest(c)
+	if err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	var req webhookSubscriptionRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	sub, err := h.srv.CreateWebhookSubscription(c, userUUID, req.URL)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, webhookSubscriptionResponse{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Secret:    sub.Secret,
+		CreatedAt: sub.CreatedAt.Format(time.RFC3339),
+	})
+	return nil
+}
+
+// getWebhookSubscriptions is a handler that lists the caller's own webhook subscriptions
+func (h *Handlers) getWebhookSubscriptions(c *gin.Context) error {
+	userUUID, err := h.getUserUUIDFromRequest(c)
+	if err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	subs, err := h.srv.ListWebhookSubscriptions(c, userUUID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, subs)
+	return nil
+}
+
+// deleteWebhookSubscription is a handler that removes one of the caller's own webhook subscriptions
+func (h *Handlers) deleteWebhookSubscription(c *gin.Context) error {
+	userUUID, err := h.getUserUUIDFromRequest(c)
+	if err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	if err = h.srv.DeleteWebhookSubscription(c, userUUID, id); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook subscription deleted"})
+	return nil
+}
+
+// adminPostWebhookSubscription is a handler that registers a global callback URL receiving every
+// user's order and balance events, restricted to admin/support callers
+func (h *Handlers) adminPostWebhookSubscription(c *gin.Context) error {
+	var req webhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apperrors.ErrBadRequest.WithErr(err)
+	}
+
+	sub, err := h.srv.AdminCreateWebhookSubscription(c, req.URL)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, webhookSubscriptionResponse{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Secret:    sub.Secret,
+		CreatedAt: sub.CreatedAt.Format(time.RFC3339),
+	})
+	return nil
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collector owns every metric this service exposes and the registry they're registered against,
+// so Handlers, postgresql.DB and Requestor can all record to it without importing one another.
+type Collector struct {
+	registry *prometheus.Registry
+
+	httpRequestsTotal   *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	dbQueryDuration *prometheus.HistogramVec
+	dbPoolInUse     prometheus.Gauge
+	dbPoolIdle      prometheus.Gauge
+	dbPoolTotal     prometheus.Gauge
+	dbPoolWaitCount prometheus.Gauge
+	dbPoolWaitTime  prometheus.Gauge
+
+	unprocessedOrders prometheus.Gauge
+
+	requestorOutcomesTotal *prometheus.CounterVec
+}
+
+// NewCollector creates a Collector and registers all of its metrics against a fresh registry
+func NewCollector() *Collector {
+	c := &Collector{registry: prometheus.NewRegistry()}
+
+	c.httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests processed, labeled by method, path and status",
+	}, []string{"method", "path", "status"})
+
+	c.httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, labeled by method, path and status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+
+	c.dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "postgresql.DB method duration in seconds, labeled by method name",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"query"})
+
+	c.dbPoolInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_in_use_connections", Help: "Connections currently checked out of the pgx pool",
+	})
+	c.dbPoolIdle = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_idle_connections", Help: "Idle connections held by the pgx pool",
+	})
+	c.dbPoolTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_total_connections", Help: "Total connections held by the pgx pool",
+	})
+	c.dbPoolWaitCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_wait_count", Help: "Cumulative count of connection acquires that had to wait for a free connection",
+	})
+	c.dbPoolWaitTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_wait_seconds_total", Help: "Cumulative time spent waiting for a free connection, in seconds",
+	})
+
+	c.unprocessedOrders = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "unprocessed_orders", Help: "Orders currently in NEW or PROCESSING status",
+	})
+
+	c.requestorOutcomesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "requestor_outcomes_total",
+		Help: "Requestor worker outcomes, labeled by the accrual response outcome",
+	}, []string{"outcome"})
+
+	c.registry.MustRegister(
+		c.httpRequestsTotal, c.httpRequestDuration,
+		c.dbQueryDuration,
+		c.dbPoolInUse, c.dbPoolIdle, c.dbPoolTotal, c.dbPoolWaitCount, c.dbPoolWaitTime,
+		c.unprocessedOrders,
+		c.requestorOutcomesTotal,
+	)
+
+	return c
+}
+
+// Handler returns the http.Handler that serves this Collector's registry in the Prometheus
+// exposition format, mounted by Handlers at /metrics
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records one HTTP request's outcome and latency
+func (c *Collector) ObserveHTTPRequest(method, path, status string, duration time.Duration) {
+	c.httpRequestsTotal.WithLabelValues(method, path, status).Inc()
+	c.httpRequestDuration.WithLabelValues(method, path, status).Observe(duration.Seconds())
+}
+
+// ObserveDBQuery records how long a postgresql.DB method took, labeled by its name
+func (c *Collector) ObserveDBQuery(query string, duration time.Duration) {
+	c.dbQueryDuration.WithLabelValues(query).Observe(duration.Seconds())
+}
+
+// RecordPoolStats snapshots a pgxpool.Stat into the pool gauges, called periodically from the
+// repository layer
+func (c *Collector) RecordPoolStats(stat *pgxpool.Stat) {
+	c.dbPoolInUse.Set(float64(stat.AcquiredConns()))
+	c.dbPoolIdle.Set(float64(stat.IdleConns()))
+	c.dbPoolTotal.Set(float64(stat.TotalConns()))
+	c.dbPoolWaitCount.Set(float64(stat.EmptyAcquireCount()))
+	c.dbPoolWaitTime.Set(stat.AcquireDuration().Seconds())
+}
+
+// SetUnprocessedOrders records the current unprocessed order backlog size
+func (c *Collector) SetUnprocessedOrders(count int64) {
+	c.unprocessedOrders.Set(float64(count))
+}
+
+// ObserveRequestorOutcome increments the counter for a single requestor worker outcome, one of
+// "ok", "no_content", "too_many_requests" or "error"
+func (c *Collector) ObserveRequestorOutcome(outcome string) {
+	c.requestorOutcomesTotal.WithLabelValues(outcome).Inc()
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/ar4ie13/loyaltysystem/internal/role"
 	"github.com/google/uuid"
 )
 
@@ -10,12 +11,44 @@ type User struct {
 	UUID         uuid.UUID `json:"uuid" db:"uuid"`
 	Login        string    `json:"login" db:"login"`
 	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         role.Role `json:"role" db:"role"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 	Balance      float64   `json:"balance" db:"balance"`
 	Withdrawn    float64   `json:"withdrawn" db:"withdrawn"`
 }
 
+// LinkedIdentity links a local user to an external identity provider account
+type LinkedIdentity struct {
+	UserUUID    uuid.UUID `json:"user_uuid" db:"user_uuid"`
+	Provider    string    `json:"provider" db:"provider"`
+	ExternalSub string    `json:"external_sub" db:"external_sub"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// RefreshToken is a server-side, rotatable opaque token used to mint new access tokens. UserAgent
+// and IP record where the session was created, so a user reviewing their active sessions (or an
+// admin investigating a compromise) can tell them apart.
+type RefreshToken struct {
+	JTI        string     `json:"jti" db:"jti"`
+	UserUUID   uuid.UUID  `json:"user_uuid" db:"user_uuid"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IP         string     `json:"ip" db:"ip"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *string    `json:"replaced_by,omitempty" db:"replaced_by"`
+}
+
+// AuditLogEntry records an administrative action taken against a user's account
+type AuditLogEntry struct {
+	ID         int64     `json:"id" db:"id"`
+	ActorUUID  uuid.UUID `json:"actor_uuid" db:"actor_uuid"`
+	Action     string    `json:"action" db:"action"`
+	TargetUUID uuid.UUID `json:"target_uuid" db:"target_uuid"`
+	Details    string    `json:"details" db:"details"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
 type Order struct {
 	OrderNumber string    `json:"number" db:"order_num"`
 	Status      string    `json:"status" db:"status"`
@@ -24,3 +57,24 @@ type Order struct {
 	UserUUID    uuid.UUID `json:"user_uuid" db:"user_uuid"`
 	CreatedAt   time.Time `json:"uploaded_at" db:"created_at"`
 }
+
+// WebhookSubscription is a registered HTTP(S) callback URL that receives order/balance events. A
+// nil UserUUID marks a global subscription, created by an admin, that receives every user's events.
+type WebhookSubscription struct {
+	ID        int64      `json:"id" db:"id"`
+	UserUUID  *uuid.UUID `json:"user_uuid,omitempty" db:"user_uuid"`
+	URL       string     `json:"url" db:"url"`
+	Secret    string     `json:"-" db:"secret"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery is a claimed webhook_events row ready for delivery, joined with the subscription
+// it targets
+type WebhookDelivery struct {
+	ID        int64
+	URL       string
+	Secret    string
+	EventType string
+	Payload   []byte
+	Attempts  int
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	lockoutThreshold = 5
+	lockoutBase      = time.Second
+	lockoutMax       = 15 * time.Minute
+
+	// loginSweepInterval controls how often MemoryLoginAttemptTracker discards stale entries,
+	// mirroring revocationcache.sweepInterval
+	loginSweepInterval = time.Minute
+
+	// staleLoginStateAge is how long an entry can sit untouched before the sweep reclaims it. It's
+	// well above lockoutMax, so a login that's still locked out is never swept out from under itself.
+	staleLoginStateAge = time.Hour
+)
+
+// LoginAttemptTracker records failed logins per login and escalates a temporary lockout with
+// exponential backoff once lockoutThreshold is crossed. Pluggable like Store, with an in-memory
+// default.
+type LoginAttemptTracker interface {
+	// RecordFailure registers a failed login attempt and returns the lockout duration now in
+	// effect, zero if login is still under the failure threshold
+	RecordFailure(login string) time.Duration
+	// RecordSuccess clears the failure count for login
+	RecordSuccess(login string)
+	// LockedFor returns the remaining lockout duration for login, zero if it is not locked out
+	LockedFor(login string) time.Duration
+}
+
+type loginState struct {
+	failures  int
+	lockedTil time.Time
+	lastSeen  time.Time
+}
+
+// MemoryLoginAttemptTracker is the default in-process LoginAttemptTracker
+type MemoryLoginAttemptTracker struct {
+	mu    sync.Mutex
+	state map[string]*loginState
+}
+
+// NewMemoryLoginAttemptTracker creates an empty in-memory LoginAttemptTracker and starts its
+// background sweep loop
+func NewMemoryLoginAttemptTracker() *MemoryLoginAttemptTracker {
+	t := &MemoryLoginAttemptTracker{state: make(map[string]*loginState)}
+	go t.sweepLoop()
+	return t
+}
+
+// sweepLoop periodically discards entries that haven't been touched in staleLoginStateAge, bounding
+// MemoryLoginAttemptTracker's steady-state size instead of letting it grow for as long as the
+// process runs
+func (t *MemoryLoginAttemptTracker) sweepLoop() {
+	ticker := time.NewTicker(loginSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.sweep()
+	}
+}
+
+// sweep removes every entry whose lastSeen is older than staleLoginStateAge
+func (t *MemoryLoginAttemptTracker) sweep() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for login, s := range t.state {
+		if now.Sub(s.lastSeen) > staleLoginStateAge {
+			delete(t.state, login)
+		}
+	}
+}
+
+// RecordFailure implements LoginAttemptTracker
+func (t *MemoryLoginAttemptTracker) RecordFailure(login string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.state[login]
+	if !ok {
+		s = &loginState{}
+		t.state[login] = s
+	}
+	s.failures++
+	s.lastSeen = time.Now()
+
+	if s.failures <= lockoutThreshold {
+		return 0
+	}
+
+	backoff := lockoutBase << (s.failures - lockoutThreshold - 1)
+	if backoff <= 0 || backoff > lockoutMax {
+		backoff = lockoutMax
+	}
+	s.lockedTil = time.Now().Add(backoff)
+	return backoff
+}
+
+// RecordSuccess implements LoginAttemptTracker
+func (t *MemoryLoginAttemptTracker) RecordSuccess(login string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.state, login)
+}
+
+// LockedFor implements LoginAttemptTracker
+func (t *MemoryLoginAttemptTracker) LockedFor(login string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.state[login]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(s.lockedTil)
+	if remaining <= 0 {
+		return 0
+	}
+	return remaining
+}
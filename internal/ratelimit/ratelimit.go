@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit describes a token-bucket rate: burst tokens are available immediately, refilled at a rate
+// of Rate tokens per Interval
+type Limit struct {
+	Rate     int
+	Interval time.Duration
+	Burst    int
+}
+
+// Store is the pluggable backend for token-bucket state, keyed by caller (IP or user UUID). The
+// default, MemoryStore, is suitable for a single instance; a Redis-backed Store can be swapped in
+// to share limits across horizontally scaled instances.
+type Store interface {
+	// Allow consumes a token from the bucket identified by key under limit, reporting whether the
+	// request is allowed and, if not, how long the caller should wait before retrying
+	Allow(key string, limit Limit) (allowed bool, retryAfter time.Duration)
+}
+
+// bucket is a single token bucket's mutable state
+type bucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// sweepInterval controls how often MemoryStore discards stale buckets, mirroring
+// revocationcache.sweepInterval
+const sweepInterval = time.Minute
+
+// staleBucketAge is how long a bucket can sit untouched before the sweep reclaims it. It's well
+// above any realistic Limit.Interval, so a caller that's merely been quiet doesn't lose state it's
+// still using.
+const staleBucketAge = time.Hour
+
+// MemoryStore is an in-process, map-backed Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore creates an empty in-memory token-bucket Store and starts its background sweep loop
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{buckets: make(map[string]*bucket)}
+	go s.sweepLoop()
+	return s
+}
+
+// sweepLoop periodically discards buckets that haven't been touched in staleBucketAge, bounding
+// MemoryStore's steady-state size instead of letting it grow for as long as the process runs
+func (s *MemoryStore) sweepLoop() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.sweep()
+	}
+}
+
+// sweep removes every bucket whose lastRefill is older than staleBucketAge
+func (s *MemoryStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, b := range s.buckets {
+		if now.Sub(b.lastRefill) > staleBucketAge {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Allow implements Store
+func (s *MemoryStore) Allow(key string, limit Limit) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), lastRefill: now}
+		s.buckets[key] = b
+	}
+
+	refillRate := float64(limit.Rate) / limit.Interval.Seconds()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * refillRate
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.lastRefill = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / refillRate * float64(time.Second))
+	return false, wait
+}
@@ -2,35 +2,165 @@ package postgresql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/metrics"
 	"github.com/ar4ie13/loyaltysystem/internal/models"
 	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql/config"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 )
 
+// poolStatsInterval and backlogInterval control how often NewDB's background loops refresh the
+// pgx pool and unprocessed-order-backlog gauges
+const (
+	poolStatsInterval    = 15 * time.Second
+	backlogInterval      = 15 * time.Second
+	sessionSweepInterval = 1 * time.Hour
+)
+
+// webhookOrderEvent is the JSON payload enqueued for order/balance webhook events
+type webhookOrderEvent struct {
+	Event     string    `json:"event"`
+	Order     string    `json:"order"`
+	UserUUID  uuid.UUID `json:"user_uuid"`
+	Accrual   *float64  `json:"accrual,omitempty"`
+	Withdrawn *float64  `json:"withdrawn,omitempty"`
+	Timestamp int64     `json:"ts"`
+}
+
+// emitWebhookEvent fans an event out to every subscription that should receive it (the owning
+// user's own subscriptions plus every global one) as a row in webhook_events, written on tx so it
+// is only durable if the state change it describes is also committed (transactional outbox)
+func emitWebhookEvent(ctx context.Context, tx pgx.Tx, userUUID uuid.UUID, eventType string, payload []byte) error {
+	const query = `
+		INSERT INTO webhook_events (subscription_id, event_type, payload, created_at, next_attempt_at)
+		SELECT id, $1, $2, now(), now() FROM webhooks WHERE user_uuid = $3 OR user_uuid IS NULL`
+
+	if _, err := tx.Exec(ctx, query, eventType, payload, userUUID); err != nil {
+		return fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+	return nil
+}
+
 // DB is a main postgres repository object
 type DB struct {
-	pool *pgxpool.Pool
-	zlog zerolog.Logger
+	pool    *pgxpool.Pool
+	zlog    zerolog.Logger
+	metrics *metrics.Collector
 }
 
-// NewDB construct postgres DB object
-func NewDB(ctx context.Context, cfg config.PGConf, zlog zerolog.Logger) (*DB, error) {
+// NewDB construct postgres DB object and starts its background pool-stats and backlog gauge loops
+func NewDB(ctx context.Context, cfg config.PGConf, zlog zerolog.Logger, mtr *metrics.Collector) (*DB, error) {
 	pool, err := initPool(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize a connection pool: %w", err)
 	}
-	return &DB{
-		pool: pool,
-		zlog: zlog,
-	}, nil
+	db := &DB{
+		pool:    pool,
+		zlog:    zlog,
+		metrics: mtr,
+	}
+	go db.poolStatsLoop()
+	go db.backlogLoop()
+	go db.sessionSweepLoop()
+	return db, nil
+}
+
+// trackQuery returns a function that, when deferred, records how long the calling method took into
+// the db_query_duration_seconds histogram, labeled by name
+func (db *DB) trackQuery(name string) func() {
+	start := time.Now()
+	return func() {
+		db.metrics.ObserveDBQuery(name, time.Since(start))
+	}
+}
+
+// poolStatsLoop periodically snapshots the pgx pool's stats into the pool gauges
+func (db *DB) poolStatsLoop() {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		db.metrics.RecordPoolStats(db.pool.Stat())
+	}
+}
+
+// backlogLoop periodically counts unprocessed orders and records them as a gauge
+func (db *DB) backlogLoop() {
+	ticker := time.NewTicker(backlogInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := db.countUnprocessedOrders(context.Background())
+		if err != nil {
+			db.zlog.Error().Err(err).Msg("unable to count unprocessed orders")
+			continue
+		}
+		db.metrics.SetUnprocessedOrders(count)
+	}
+}
+
+// sessionSweepLoop periodically deletes refresh tokens and blacklisted access token jtis that have
+// been expired long enough that they can no longer be replayed, keeping both tables from growing
+// without bound
+func (db *DB) sessionSweepLoop() {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := db.deleteExpiredSessions(context.Background())
+		if err != nil {
+			db.zlog.Error().Err(err).Msg("unable to sweep expired sessions")
+			continue
+		}
+		if deleted > 0 {
+			db.zlog.Debug().Msgf("session sweeper deleted %d expired rows", deleted)
+		}
+	}
+}
+
+// deleteExpiredSessions removes refresh tokens and blacklisted jtis whose expires_at has passed
+func (db *DB) deleteExpiredSessions(ctx context.Context) (int64, error) {
+	const (
+		deleteRefreshTokens = `DELETE FROM refresh_tokens WHERE expires_at < $1`
+		deleteRevokedJTIs   = `DELETE FROM revoked_jtis WHERE expires_at < $1`
+	)
+
+	now := time.Now()
+	var deleted int64
+
+	tag, err := db.pool.Exec(ctx, deleteRefreshTokens, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	deleted += tag.RowsAffected()
+
+	tag, err = db.pool.Exec(ctx, deleteRevokedJTIs, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired revoked jtis: %w", err)
+	}
+	deleted += tag.RowsAffected()
+
+	return deleted, nil
+}
+
+// countUnprocessedOrders counts orders that have not yet reached a final status
+func (db *DB) countUnprocessedOrders(ctx context.Context) (int64, error) {
+	const query = `SELECT count(*) FROM orders WHERE status IN ('NEW', 'PROCESSING')`
+
+	var count int64
+	if err := db.pool.QueryRow(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unprocessed orders: %w", err)
+	}
+	return count, nil
 }
 
 // initPool initializes pgx connection pool
@@ -57,11 +187,13 @@ func (db *DB) Close() error {
 
 // CreateUser stores user information to the db
 func (db *DB) CreateUser(ctx context.Context, user models.User) error {
+	defer db.trackQuery("CreateUser")()
+
 	const query = `
-		INSERT INTO users (uuid, login, password_hash, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (login) DO NOTHING`
+		INSERT INTO users (uuid, login, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (login) DO NOTHING`
 
-	tag, err := db.pool.Exec(ctx, query, user.UUID, user.Login, user.PasswordHash, time.Now(), time.Now())
+	tag, err := db.pool.Exec(ctx, query, user.UUID, user.Login, user.PasswordHash, user.Role, time.Now(), time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
@@ -77,13 +209,15 @@ func (db *DB) CreateUser(ctx context.Context, user models.User) error {
 
 // GetUserByLogin retrieves user information from db
 func (db *DB) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
-	const query = `SELECT uuid, login, password_hash, created_at, updated_at from users where login=$1`
+	defer db.trackQuery("GetUserByLogin")()
+
+	const query = `SELECT uuid, login, password_hash, role, created_at, updated_at from users where login=$1`
 
 	var user models.User
 
 	row := db.pool.QueryRow(ctx, query, login)
 
-	err := row.Scan(&user.UUID, &user.Login, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.UUID, &user.Login, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -98,6 +232,8 @@ func (db *DB) GetUserByLogin(ctx context.Context, login string) (models.User, er
 
 // PutUserOrder stores user's order without withdrawn to the db
 func (db *DB) PutUserOrder(ctx context.Context, userUUID uuid.UUID, order string) error {
+	defer db.trackQuery("PutUserOrder")()
+
 	const (
 		queryInsert = `
 		INSERT INTO orders (order_num, status, user_uuid, created_at)
@@ -156,14 +292,10 @@ func (db *DB) PutUserOrder(ctx context.Context, userUUID uuid.UUID, order string
 
 // GetUserOrders retrieves all user's orders from db
 func (db *DB) GetUserOrders(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error) {
-	const queryStmt = `SELECT order_num, status, accrual, user_uuid, created_at FROM orders 
-                    	WHERE user_uuid = $1 ORDER BY created_at DESC`
+	defer db.trackQuery("GetUserOrders")()
 
-	start := time.Now()
-	defer func() {
-		elapsed := time.Since(start)
-		db.zlog.Debug().Msgf("request execution duration: %s", elapsed)
-	}()
+	const queryStmt = `SELECT order_num, status, accrual, user_uuid, created_at FROM orders
+                    	WHERE user_uuid = $1 ORDER BY created_at DESC`
 
 	rows, err := db.pool.Query(ctx, queryStmt, userUUID)
 	if err != nil {
@@ -194,13 +326,28 @@ func (db *DB) GetUserOrders(ctx context.Context, userUUID uuid.UUID) ([]models.O
 	return orders, nil
 }
 
-// GetUnprocessedOrders retrieves orders withoud final status from db, used by requestor service
-func (db *DB) GetUnprocessedOrders(ctx context.Context, limit int) ([]string, error) {
-	const query = `SELECT order_num FROM orders WHERE status IN ('NEW', 'PROCESSING') ORDER BY created_at ASC LIMIT $1`
+// ClaimUnprocessedOrders atomically claims up to limit orders that are not currently leased by
+// another worker, tagging them with workerID and a lease that expires after leaseDuration. Using
+// FOR UPDATE SKIP LOCKED lets several requestor replicas poll the same table concurrently without
+// claiming the same order twice.
+func (db *DB) ClaimUnprocessedOrders(ctx context.Context, workerID string, limit int, leaseDuration time.Duration) ([]string, error) {
+	defer db.trackQuery("ClaimUnprocessedOrders")()
+
+	const query = `
+		UPDATE orders
+		SET status = 'PROCESSING', worker_id = $1, locked_until = now() + make_interval(secs => $2)
+		WHERE order_num IN (
+			SELECT order_num FROM orders
+			WHERE status IN ('NEW', 'PROCESSING') AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY created_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING order_num`
 
 	var orderNums []string
 
-	rows, err := db.pool.Query(ctx, query, limit)
+	rows, err := db.pool.Query(ctx, query, workerID, leaseDuration.Seconds(), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -223,56 +370,130 @@ func (db *DB) GetUnprocessedOrders(ctx context.Context, limit int) ([]string, er
 	return orderNums, nil
 }
 
-// UpdateOrderWithoutAccrual updates status for orders without accrual, used by requestor service
+// ReleaseOrderLease clears the lease on an order a worker gave up on without reaching a final
+// status, letting the next ClaimUnprocessedOrders pick it up immediately instead of waiting for
+// the lease to expire
+func (db *DB) ReleaseOrderLease(ctx context.Context, orderNum string) error {
+	defer db.trackQuery("ReleaseOrderLease")()
+
+	const query = `UPDATE orders SET worker_id = NULL, locked_until = NULL WHERE order_num = $1 AND status = 'PROCESSING'`
+
+	if _, err := db.pool.Exec(ctx, query, orderNum); err != nil {
+		return fmt.Errorf("failed to release order lease: %w", err)
+	}
+
+	return nil
+}
+
+// ResetStuckOrders is the janitor query: it clears the lease of any order whose locked_until has
+// passed, in case a worker crashed mid-processing and never released it. It returns the number of
+// rows reset.
+func (db *DB) ResetStuckOrders(ctx context.Context) (int64, error) {
+	defer db.trackQuery("ResetStuckOrders")()
+
+	const query = `UPDATE orders SET worker_id = NULL, locked_until = NULL WHERE status = 'PROCESSING' AND locked_until < now()`
+
+	tag, err := db.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset stuck orders: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// UpdateOrderWithoutAccrual updates status for orders without accrual, used by requestor service.
+// The status change and its webhook event are written in the same transaction (transactional outbox).
 func (db *DB) UpdateOrderWithoutAccrual(ctx context.Context, orderNum string, status string) error {
+	defer db.trackQuery("UpdateOrderWithoutAccrual")()
 
-	queryUpdOrders := `UPDATE orders  SET status = $1 WHERE order_num = $2`
-	tag, err := db.pool.Exec(ctx, queryUpdOrders, status, orderNum)
+	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.ReadCommitted,
+	})
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	const queryUpdOrders = `UPDATE orders SET status = $1, worker_id = NULL, locked_until = NULL WHERE order_num = $2 RETURNING user_uuid`
+
+	var userUUID uuid.UUID
+	row := tx.QueryRow(ctx, queryUpdOrders, status, orderNum)
+	if err = row.Scan(&userUUID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("no rows were updated")
+		}
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
-	rowsInserted := tag.RowsAffected()
+	payload, err := json.Marshal(webhookOrderEvent{
+		Event:     "order.status_changed",
+		Order:     orderNum,
+		UserUUID:  userUUID,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
 
-	if rowsInserted == 0 {
-		return fmt.Errorf("no rows were updated")
+	if err = emitWebhookEvent(ctx, tx, userUUID, "order.status_changed", payload); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-// UpdateOrderWithAccrual updates status for orders with accrual, used by requestor service
+// UpdateOrderWithAccrual updates status and accrual for a processed order and credits the user's
+// balance, used by requestor service. The balance change and its webhook event are written in the
+// same transaction as the order update (transactional outbox).
 func (db *DB) UpdateOrderWithAccrual(ctx context.Context, orderNum string, status string, accrual float64) error {
-	// Begin transaction
+	defer db.trackQuery("UpdateOrderWithAccrual")()
+
 	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to start a transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	queryUpdOrders := `UPDATE  orders  SET accrual = $1, status = $2 WHERE order_num = $3`
-	tag, err := db.pool.Exec(ctx, queryUpdOrders, accrual, status, orderNum)
-	if err != nil {
+	const queryUpdOrders = `UPDATE orders SET accrual = $1, status = $2, worker_id = NULL, locked_until = NULL WHERE order_num = $3 RETURNING user_uuid`
+
+	var userUUID uuid.UUID
+	row := tx.QueryRow(ctx, queryUpdOrders, accrual, status, orderNum)
+	if err = row.Scan(&userUUID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("no rows were updated during order update")
+		}
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
-	rowsInserted := tag.RowsAffected()
+	const queryUpdUsers = `UPDATE users SET balance = balance + $1, updated_at = $2 WHERE uuid = $3`
+	tag, err := tx.Exec(ctx, queryUpdUsers, accrual, time.Now(), userUUID)
+	if err != nil {
+		return fmt.Errorf("failed to update user balance: %w", err)
+	}
 
-	if rowsInserted == 0 {
-		return fmt.Errorf("no rows were updated during order update")
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no rows were updated during user balance update")
 	}
 
-	queryUpdUsers := `UPDATE  users  SET balance = balance + $1, updated_at = $2  WHERE uuid = (SELECT user_uuid from orders where order_num = $3)`
-	tag, err = db.pool.Exec(ctx, queryUpdUsers, accrual, time.Now(), orderNum)
+	payload, err := json.Marshal(webhookOrderEvent{
+		Event:     "order.processed",
+		Order:     orderNum,
+		UserUUID:  userUUID,
+		Accrual:   &accrual,
+		Timestamp: time.Now().Unix(),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update user balance: %w", err)
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
-	rowsInserted = tag.RowsAffected()
-
-	if rowsInserted == 0 {
-		return fmt.Errorf("no rows were updated during user balance update")
+	if err = emitWebhookEvent(ctx, tx, userUUID, "order.processed", payload); err != nil {
+		return err
 	}
 
 	// Commit transaction
@@ -285,6 +506,8 @@ func (db *DB) UpdateOrderWithAccrual(ctx context.Context, orderNum string, statu
 
 // GetBalance retrieves user's balance from db
 func (db *DB) GetBalance(ctx context.Context, user uuid.UUID) (models.User, error) {
+	defer db.trackQuery("GetBalance")()
+
 	const queryStmt = `SELECT balance, withdrawn FROM users 
                     	WHERE uuid = $1`
 	var balance models.User
@@ -300,6 +523,8 @@ func (db *DB) GetBalance(ctx context.Context, user uuid.UUID) (models.User, erro
 
 // PutUserWithdrawnOrder stores user's order with withdrawn to the db
 func (db *DB) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNum string, withdrawn float64) error {
+	defer db.trackQuery("PutUserWithdrawnOrder")()
+
 	const (
 		querySelect = `SELECT balance FROM users WHERE uuid = $1 FOR UPDATE`
 		queryInsert = `INSERT INTO orders (order_num, status, user_uuid, withdrawn, created_at) 
@@ -315,10 +540,11 @@ func (db *DB) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNu
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
 	// Checking user balance
 	var balance models.User
-	row := db.pool.QueryRow(ctx, querySelect, user)
+	row := tx.QueryRow(ctx, querySelect, user)
 	err = row.Scan(&balance.Balance)
 	if err != nil {
 		db.zlog.Error().Msgf("failed to query user balance: %v", err)
@@ -330,7 +556,7 @@ func (db *DB) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNu
 	}
 
 	// Inserting order
-	tag, err := db.pool.Exec(ctx, queryInsert, orderNum, "PROCESSED", user, withdrawn, time.Now())
+	tag, err := tx.Exec(ctx, queryInsert, orderNum, "PROCESSED", user, withdrawn, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to insert order balance: %w", err)
 	}
@@ -342,7 +568,7 @@ func (db *DB) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNu
 	}
 
 	// Updating user balance
-	tag, err = db.pool.Exec(ctx, queryUpdate, withdrawn, time.Now(), user)
+	tag, err = tx.Exec(ctx, queryUpdate, withdrawn, time.Now(), user)
 	if err != nil {
 		return fmt.Errorf("failed to update user balance: %w", err)
 	}
@@ -353,6 +579,21 @@ func (db *DB) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNu
 		return fmt.Errorf("no rows were updated during user balance update")
 	}
 
+	payload, err := json.Marshal(webhookOrderEvent{
+		Event:     "balance.withdrawn",
+		Order:     orderNum,
+		UserUUID:  user,
+		Withdrawn: &withdrawn,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	if err = emitWebhookEvent(ctx, tx, user, "balance.withdrawn", payload); err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -361,16 +602,205 @@ func (db *DB) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNu
 	return nil
 }
 
+// CreateRefreshToken stores a new refresh token for a user
+func (db *DB) CreateRefreshToken(ctx context.Context, rt models.RefreshToken) error {
+	defer db.trackQuery("CreateRefreshToken")()
+
+	const query = `INSERT INTO refresh_tokens (jti, user_uuid, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := db.pool.Exec(ctx, query, rt.JTI, rt.UserUUID, rt.UserAgent, rt.IP, rt.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token by its jti
+func (db *DB) GetRefreshToken(ctx context.Context, jti string) (models.RefreshToken, error) {
+	defer db.trackQuery("GetRefreshToken")()
+
+	const query = `SELECT jti, user_uuid, user_agent, ip, expires_at, revoked_at, replaced_by FROM refresh_tokens WHERE jti = $1`
+
+	var rt models.RefreshToken
+
+	row := db.pool.QueryRow(ctx, query, jti)
+
+	err := row.Scan(&rt.JTI, &rt.UserUUID, &rt.UserAgent, &rt.IP, &rt.ExpiresAt, &rt.RevokedAt, &rt.ReplacedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return models.RefreshToken{}, apperrors.ErrRefreshTokenNotFound
+		default:
+			return models.RefreshToken{}, fmt.Errorf("failed to scan a response row: %w", err)
+		}
+	}
+
+	return rt, nil
+}
+
+// RotateRefreshToken marks oldJTI revoked/replaced and inserts its successor in a single transaction
+func (db *DB) RotateRefreshToken(ctx context.Context, oldJTI string, next models.RefreshToken) error {
+	defer db.trackQuery("RotateRefreshToken")()
+
+	const (
+		queryRevoke = `UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE jti = $3`
+		queryInsert = `INSERT INTO refresh_tokens (jti, user_uuid, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	)
+
+	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.ReadCommitted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err = tx.Exec(ctx, queryRevoke, time.Now(), next.JTI, oldJTI); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, queryInsert, next.JTI, next.UserUUID, next.UserAgent, next.IP, next.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeRefreshToken marks a single refresh token revoked, used on logout
+func (db *DB) RevokeRefreshToken(ctx context.Context, jti string) error {
+	defer db.trackQuery("RevokeRefreshToken")()
+
+	const query = `UPDATE refresh_tokens SET revoked_at = $1 WHERE jti = $2 AND revoked_at IS NULL`
+
+	if _, err := db.pool.Exec(ctx, query, time.Now(), jti); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamily walks the replaced_by chain starting at jti and revokes every token in it,
+// used when a rotated-out token is presented again (a sign the refresh token was stolen)
+func (db *DB) RevokeRefreshTokenFamily(ctx context.Context, jti string) error {
+	defer db.trackQuery("RevokeRefreshTokenFamily")()
+
+	const query = `
+		WITH RECURSIVE family AS (
+			SELECT jti, replaced_by FROM refresh_tokens WHERE jti = $1
+			UNION ALL
+			SELECT rt.jti, rt.replaced_by FROM refresh_tokens rt
+			JOIN family f ON rt.jti = f.replaced_by
+		)
+		UPDATE refresh_tokens SET revoked_at = $2
+		WHERE jti IN (SELECT jti FROM family) AND revoked_at IS NULL`
+
+	if _, err := db.pool.Exec(ctx, query, jti, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeJTI blacklists an access token's jti until it would have expired naturally
+func (db *DB) RevokeJTI(ctx context.Context, jti string, until time.Time) error {
+	defer db.trackQuery("RevokeJTI")()
+
+	const query = `
+		INSERT INTO revoked_jtis (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := db.pool.Exec(ctx, query, jti, until); err != nil {
+		return fmt.Errorf("failed to blacklist jti: %w", err)
+	}
+
+	return nil
+}
+
+// IsJTIRevoked reports whether an access token's jti has been blacklisted
+func (db *DB) IsJTIRevoked(ctx context.Context, jti string) (bool, error) {
+	defer db.trackQuery("IsJTIRevoked")()
+
+	const query = `SELECT EXISTS(SELECT 1 FROM revoked_jtis WHERE jti = $1 AND expires_at > $2)`
+
+	var revoked bool
+
+	row := db.pool.QueryRow(ctx, query, jti, time.Now())
+	if err := row.Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check jti revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// GetUserByExternalSub retrieves the user linked to the given IdP subject
+func (db *DB) GetUserByExternalSub(ctx context.Context, provider, sub string) (models.User, error) {
+	defer db.trackQuery("GetUserByExternalSub")()
+
+	const query = `
+		SELECT u.uuid, u.login, u.password_hash, u.role, u.created_at, u.updated_at FROM users u
+		JOIN linked_identities li ON li.user_uuid = u.uuid
+		WHERE li.provider = $1 AND li.external_sub = $2`
+
+	var user models.User
+
+	row := db.pool.QueryRow(ctx, query, provider, sub)
+
+	err := row.Scan(&user.UUID, &user.Login, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return models.User{}, apperrors.ErrUserNotFound
+		default:
+			return models.User{}, fmt.Errorf("failed to scan a response row: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// CreateUserWithExternalSub stores a new user together with its linked external identity
+func (db *DB) CreateUserWithExternalSub(ctx context.Context, user models.User, provider, sub string) error {
+	defer db.trackQuery("CreateUserWithExternalSub")()
+
+	const (
+		queryInsertUser     = `INSERT INTO users (uuid, login, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`
+		queryInsertIdentity = `INSERT INTO linked_identities (user_uuid, provider, external_sub, created_at) VALUES ($1, $2, $3, $4)`
+	)
+
+	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.ReadCommitted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	if _, err = tx.Exec(ctx, queryInsertUser, user.UUID, user.Login, user.PasswordHash, user.Role, now, now); err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, queryInsertIdentity, user.UUID, provider, sub, now); err != nil {
+		return fmt.Errorf("failed to insert linked identity: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 // GetUserWithdrawals retrieves all users withdrawals from the db
 func (db *DB) GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error) {
-	const queryStmt = `SELECT order_num, withdrawn, created_at FROM orders 
-                    	WHERE user_uuid = $1 AND withdrawn IS NOT NULL ORDER BY created_at DESC`
+	defer db.trackQuery("GetUserWithdrawals")()
 
-	start := time.Now()
-	defer func() {
-		elapsed := time.Since(start)
-		db.zlog.Debug().Msgf("request execution duration: %s", elapsed)
-	}()
+	const queryStmt = `SELECT order_num, withdrawn, created_at FROM orders
+                    	WHERE user_uuid = $1 AND withdrawn IS NOT NULL ORDER BY created_at DESC`
 
 	rows, err := db.pool.Query(ctx, queryStmt, userUUID)
 	if err != nil {
@@ -400,3 +830,288 @@ func (db *DB) GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]mod
 
 	return orders, nil
 }
+
+// GetUserRole retrieves the role currently assigned to a user
+func (db *DB) GetUserRole(ctx context.Context, userUUID uuid.UUID) (role.Role, error) {
+	defer db.trackQuery("GetUserRole")()
+
+	const query = `SELECT role FROM users WHERE uuid = $1`
+
+	var userRole role.Role
+
+	row := db.pool.QueryRow(ctx, query, userUUID)
+
+	err := row.Scan(&userRole)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return "", apperrors.ErrUserNotFound
+		default:
+			return "", fmt.Errorf("failed to scan a response row: %w", err)
+		}
+	}
+
+	return userRole, nil
+}
+
+// ListUsers retrieves every registered user from the db, used by the admin subsystem
+func (db *DB) ListUsers(ctx context.Context) ([]models.User, error) {
+	defer db.trackQuery("ListUsers")()
+
+	const query = `SELECT uuid, login, role, created_at, updated_at, balance, withdrawn FROM users ORDER BY created_at ASC`
+
+	rows, err := db.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+
+	for rows.Next() {
+		var user models.User
+
+		err = rows.Scan(&user.UUID, &user.Login, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.Balance, &user.Withdrawn)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// AdminAdjustBalance applies a manual balance delta to a user's account, used by the admin subsystem
+func (db *DB) AdminAdjustBalance(ctx context.Context, user uuid.UUID, delta float64) error {
+	defer db.trackQuery("AdminAdjustBalance")()
+
+	const query = `UPDATE users SET balance = balance + $1, updated_at = $2 WHERE uuid = $3`
+
+	tag, err := db.pool.Exec(ctx, query, delta, time.Now(), user)
+	if err != nil {
+		return fmt.Errorf("failed to adjust user balance: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllUserRefreshTokens revokes every active refresh token belonging to a user, used by the
+// admin subsystem to terminate all of a user's sessions
+func (db *DB) RevokeAllUserRefreshTokens(ctx context.Context, userUUID uuid.UUID) error {
+	defer db.trackQuery("RevokeAllUserRefreshTokens")()
+
+	const query = `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_uuid = $2 AND revoked_at IS NULL`
+
+	if _, err := db.pool.Exec(ctx, query, time.Now(), userUUID); err != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// CreateAuditLogEntry stores a record of an administrative action to the db
+func (db *DB) CreateAuditLogEntry(ctx context.Context, entry models.AuditLogEntry) error {
+	defer db.trackQuery("CreateAuditLogEntry")()
+
+	const query = `
+		INSERT INTO audit_log (actor_uuid, action, target_uuid, details, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := db.pool.Exec(ctx, query, entry.ActorUUID, entry.Action, entry.TargetUUID, entry.Details, time.Now()); err != nil {
+		return fmt.Errorf("failed to insert audit log entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetAuditLog retrieves the most recent administrative actions from the db
+func (db *DB) GetAuditLog(ctx context.Context, limit int) ([]models.AuditLogEntry, error) {
+	defer db.trackQuery("GetAuditLog")()
+
+	const query = `
+		SELECT id, actor_uuid, action, target_uuid, details, created_at FROM audit_log
+		ORDER BY created_at DESC LIMIT $1`
+
+	rows, err := db.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.AuditLogEntry
+
+	for rows.Next() {
+		var entry models.AuditLogEntry
+
+		err = rows.Scan(&entry.ID, &entry.ActorUUID, &entry.Action, &entry.TargetUUID, &entry.Details, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// CreateWebhookSubscription stores a new webhook subscription and returns it with its generated id
+// and created_at. A nil UserUUID stores a global subscription.
+func (db *DB) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	defer db.trackQuery("CreateWebhookSubscription")()
+
+	const query = `
+		INSERT INTO webhooks (user_uuid, url, secret, created_at)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	row := db.pool.QueryRow(ctx, query, sub.UserUUID, sub.URL, sub.Secret, time.Now())
+	if err := row.Scan(&sub.ID, &sub.CreatedAt); err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("failed to insert webhook subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ListWebhookSubscriptions retrieves every webhook subscription owned by a user
+func (db *DB) ListWebhookSubscriptions(ctx context.Context, userUUID uuid.UUID) ([]models.WebhookSubscription, error) {
+	defer db.trackQuery("ListWebhookSubscriptions")()
+
+	const query = `SELECT id, user_uuid, url, created_at FROM webhooks WHERE user_uuid = $1 ORDER BY created_at DESC`
+
+	rows, err := db.pool.Query(ctx, query, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []models.WebhookSubscription
+
+	for rows.Next() {
+		var sub models.WebhookSubscription
+
+		if err = rows.Scan(&sub.ID, &sub.UserUUID, &sub.URL, &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes a webhook subscription, scoped to the owning user so a caller
+// cannot delete another user's or a global subscription
+func (db *DB) DeleteWebhookSubscription(ctx context.Context, userUUID uuid.UUID, id int64) error {
+	defer db.trackQuery("DeleteWebhookSubscription")()
+
+	const query = `DELETE FROM webhooks WHERE id = $1 AND user_uuid = $2`
+
+	tag, err := db.pool.Exec(ctx, query, id, userUUID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ClaimPendingWebhookEvents atomically claims up to limit due webhook_events rows, excluding ones
+// that have already exhausted maxAttempts, tagging them with workerID and a lease that expires
+// after leaseDuration. Mirrors ClaimUnprocessedOrders so several dispatcher replicas can poll the
+// same table without delivering the same event twice.
+func (db *DB) ClaimPendingWebhookEvents(ctx context.Context, workerID string, limit int, leaseDuration time.Duration, maxAttempts int) ([]models.WebhookDelivery, error) {
+	defer db.trackQuery("ClaimPendingWebhookEvents")()
+
+	const query = `
+		UPDATE webhook_events we
+		SET worker_id = $1, locked_until = now() + make_interval(secs => $2)
+		FROM webhooks w
+		WHERE w.id = we.subscription_id AND we.id IN (
+			SELECT id FROM webhook_events
+			WHERE delivered_at IS NULL AND next_attempt_at <= now() AND attempts < $3
+				AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY created_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING we.id, w.url, w.secret, we.event_type, we.payload, we.attempts`
+
+	rows, err := db.pool.Query(ctx, query, workerID, leaseDuration.Seconds(), maxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+
+		if err = rows.Scan(&d.ID, &d.URL, &d.Secret, &d.EventType, &d.Payload, &d.Attempts); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// MarkWebhookEventDelivered marks a webhook_events row as successfully delivered
+func (db *DB) MarkWebhookEventDelivered(ctx context.Context, id int64) error {
+	defer db.trackQuery("MarkWebhookEventDelivered")()
+
+	const query = `UPDATE webhook_events SET delivered_at = now(), worker_id = NULL, locked_until = NULL WHERE id = $1`
+
+	if _, err := db.pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark webhook event delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkWebhookEventFailed records a failed delivery attempt, rescheduling the next retry for
+// nextAttemptAt. Once attempts reaches the Dispatcher's maxAttempts, ClaimPendingWebhookEvents
+// excludes the row from future claims.
+func (db *DB) MarkWebhookEventFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time) error {
+	defer db.trackQuery("MarkWebhookEventFailed")()
+
+	const query = `UPDATE webhook_events SET attempts = $1, next_attempt_at = $2, worker_id = NULL, locked_until = NULL WHERE id = $3`
+
+	if _, err := db.pool.Exec(ctx, query, attempts, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to mark webhook event failed: %w", err)
+	}
+
+	return nil
+}
+
+// ResetStuckWebhookEvents clears the lease of any webhook_events row whose locked_until has
+// passed, in case a dispatcher replica crashed mid-delivery and never released it. It returns the
+// number of rows reset.
+func (db *DB) ResetStuckWebhookEvents(ctx context.Context) (int64, error) {
+	defer db.trackQuery("ResetStuckWebhookEvents")()
+
+	const query = `UPDATE webhook_events SET worker_id = NULL, locked_until = NULL WHERE delivered_at IS NULL AND locked_until < now()`
+
+	tag, err := db.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset stuck webhook events: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
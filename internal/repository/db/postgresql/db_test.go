@@ -0,0 +1,156 @@
+package postgresql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
+	"github.com/ar4ie13/loyaltysystem/internal/metrics"
+	"github.com/ar4ie13/loyaltysystem/internal/models"
+	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql"
+	"github.com/ar4ie13/loyaltysystem/internal/repository/testhelper"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// newTestDB builds a *postgresql.DB backed by an ephemeral, freshly-migrated Postgres container
+func newTestDB(t *testing.T) *postgresql.DB {
+	t.Helper()
+
+	cfg := testhelper.NewConfig(t)
+	db, err := postgresql.NewDB(context.Background(), cfg, zerolog.Nop(), metrics.NewCollector())
+	if err != nil {
+		t.Fatalf("failed to construct test DB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// createTestUser inserts a user directly through the repository and returns its UUID
+func createTestUser(t *testing.T, db *postgresql.DB, login string) uuid.UUID {
+	t.Helper()
+
+	u := models.User{
+		UUID:         uuid.New(),
+		Login:        login,
+		PasswordHash: "hash",
+		Role:         role.RoleUser,
+	}
+	if err := db.CreateUser(context.Background(), u); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	return u.UUID
+}
+
+func TestDB_PutUserOrder(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	owner := createTestUser(t, db, "put-order-owner")
+	other := createTestUser(t, db, "put-order-other")
+
+	const orderNum = "12345678903"
+
+	if err := db.PutUserOrder(ctx, owner, orderNum); err != nil {
+		t.Fatalf("unexpected error registering a new order: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		user    uuid.UUID
+		wantErr *apperrors.AppError
+	}{
+		{name: "same user re-submits the same order", user: owner, wantErr: apperrors.ErrOrderAlreadyExists},
+		{name: "different user submits the same order number", user: other, wantErr: apperrors.ErrOrderNumberAlreadyUsed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.PutUserOrder(ctx, tt.user, orderNum)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PutUserOrder() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDB_PutUserWithdrawnOrder(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	rich := createTestUser(t, db, "withdraw-rich")
+	poor := createTestUser(t, db, "withdraw-poor")
+
+	if err := db.AdminAdjustBalance(ctx, rich, 500); err != nil {
+		t.Fatalf("failed to fund test user: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		user      uuid.UUID
+		orderNum  string
+		withdrawn float64
+		wantErr   *apperrors.AppError
+	}{
+		{name: "sufficient balance", user: rich, orderNum: "2377225624", withdrawn: 100, wantErr: nil},
+		{name: "insufficient balance", user: poor, orderNum: "4561261212", withdrawn: 100, wantErr: apperrors.ErrBalanceNotEnough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.PutUserWithdrawnOrder(ctx, tt.user, tt.orderNum, tt.withdrawn)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("PutUserWithdrawnOrder() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PutUserWithdrawnOrder() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	balance, err := db.GetBalance(ctx, rich)
+	if err != nil {
+		t.Fatalf("failed to fetch balance: %v", err)
+	}
+	if balance.Balance != 400 || balance.Withdrawn != 100 {
+		t.Errorf("balance after withdrawal = %+v, want Balance=400 Withdrawn=100", balance)
+	}
+}
+
+func TestDB_UpdateOrderWithAccrual(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	userUUID := createTestUser(t, db, "accrual-user")
+
+	const orderNum = "4561261212"
+	if err := db.PutUserOrder(ctx, userUUID, orderNum); err != nil {
+		t.Fatalf("failed to register order: %v", err)
+	}
+
+	if err := db.UpdateOrderWithAccrual(ctx, orderNum, "PROCESSED", 150.5); err != nil {
+		t.Fatalf("UpdateOrderWithAccrual() unexpected error: %v", err)
+	}
+
+	balance, err := db.GetBalance(ctx, userUUID)
+	if err != nil {
+		t.Fatalf("failed to fetch balance: %v", err)
+	}
+	if balance.Balance != 150.5 {
+		t.Errorf("balance after accrual = %v, want 150.5", balance.Balance)
+	}
+
+	orders, err := db.GetUserOrders(ctx, userUUID)
+	if err != nil {
+		t.Fatalf("failed to fetch orders: %v", err)
+	}
+	if len(orders) != 1 || orders[0].Status != "PROCESSED" {
+		t.Errorf("orders after accrual = %+v, want a single PROCESSED order", orders)
+	}
+}
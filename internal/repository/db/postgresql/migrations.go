@@ -0,0 +1,47 @@
+package postgresql
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql/config"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/rs/zerolog"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// ApplyMigrations brings cfg's database up to the schema every DB method in this package assumes,
+// using the embedded migrations directory as the single source of truth. It is idempotent: a
+// database already on the latest migration is left untouched.
+func ApplyMigrations(cfg config.PGConf, zlog zerolog.Logger) error {
+	source, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to load embedded migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, cfg.DatabaseDSN)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+	defer func() {
+		srcErr, dbErr := m.Close()
+		if srcErr != nil {
+			zlog.Error().Err(srcErr).Msg("failed to close migration source")
+		}
+		if dbErr != nil {
+			zlog.Error().Err(dbErr).Msg("failed to close migration database connection")
+		}
+	}()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	zlog.Info().Msg("migrations applied")
+	return nil
+}
@@ -3,14 +3,70 @@ package repository
 import (
 	"context"
 	"log"
+	"time"
 
+	"github.com/ar4ie13/loyaltysystem/internal/metrics"
+	"github.com/ar4ie13/loyaltysystem/internal/models"
 	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql"
 	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql/config"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
-func NewRepository(ctx context.Context, conf config.PGConf, zlog zerolog.Logger) (*postgresql.DB, error) {
-	repo, err := postgresql.NewDB(ctx, conf, zlog)
+// Repository lists every method the rest of the application needs from a storage backend. It is
+// satisfied today by *postgresql.DB; consumers (service.Repository, requestor.Repository, and so
+// on) each declare their own narrower subset of it, the same way Requestor.Repository already did
+// before this interface existed. Depending on Repository rather than *postgresql.DB lets
+// NewRepository swap in a different backend, or a test double, without touching its callers.
+type Repository interface {
+	Close() error
+
+	CreateUser(ctx context.Context, user models.User) error
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
+	GetUserByExternalSub(ctx context.Context, provider, sub string) (models.User, error)
+	CreateUserWithExternalSub(ctx context.Context, user models.User, provider, sub string) error
+	GetUserRole(ctx context.Context, userUUID uuid.UUID) (role.Role, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	AdminAdjustBalance(ctx context.Context, userUUID uuid.UUID, delta float64) error
+
+	PutUserOrder(ctx context.Context, userUUID uuid.UUID, order string) error
+	GetUserOrders(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error)
+	GetBalance(ctx context.Context, user uuid.UUID) (models.User, error)
+	PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNum string, withdrawn float64) error
+	GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error)
+
+	ClaimUnprocessedOrders(ctx context.Context, workerID string, limit int, leaseDuration time.Duration) ([]string, error)
+	ReleaseOrderLease(ctx context.Context, orderNum string) error
+	ResetStuckOrders(ctx context.Context) (int64, error)
+	UpdateOrderWithoutAccrual(ctx context.Context, orderNum string, status string) error
+	UpdateOrderWithAccrual(ctx context.Context, orderNum string, status string, accrual float64) error
+
+	CreateRefreshToken(ctx context.Context, rt models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, jti string) (models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldJTI string, next models.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, jti string) error
+	RevokeRefreshTokenFamily(ctx context.Context, jti string) error
+	RevokeAllUserRefreshTokens(ctx context.Context, userUUID uuid.UUID) error
+	RevokeJTI(ctx context.Context, jti string, until time.Time) error
+	IsJTIRevoked(ctx context.Context, jti string) (bool, error)
+
+	CreateAuditLogEntry(ctx context.Context, entry models.AuditLogEntry) error
+	GetAuditLog(ctx context.Context, limit int) ([]models.AuditLogEntry, error)
+
+	CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, userUUID uuid.UUID) ([]models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, userUUID uuid.UUID, id int64) error
+	ClaimPendingWebhookEvents(ctx context.Context, workerID string, limit int, leaseDuration time.Duration, maxAttempts int) ([]models.WebhookDelivery, error)
+	MarkWebhookEventDelivered(ctx context.Context, id int64) error
+	MarkWebhookEventFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time) error
+	ResetStuckWebhookEvents(ctx context.Context) (int64, error)
+}
+
+// NewRepository constructs the Postgres-backed Repository, applying pending migrations before
+// handing it back so callers never see a schema that's behind the code
+func NewRepository(ctx context.Context, conf config.PGConf, zlog zerolog.Logger, mtr *metrics.Collector) (Repository, error) {
+	repo, err := postgresql.NewDB(ctx, conf, zlog, mtr)
 	if err != nil {
 		log.Fatal(err)
 		return nil, err
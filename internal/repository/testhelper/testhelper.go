@@ -0,0 +1,92 @@
+// Package testhelper spins up an ephemeral Postgres instance for tests that need to exercise the
+// repository layer against a real database, so those tests can run in CI without a
+// pre-provisioned database.
+package testhelper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql"
+	"github.com/ar4ie13/loyaltysystem/internal/repository/db/postgresql/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	image    = "postgres:16-alpine"
+	user     = "loyalty"
+	password = "loyalty"
+	dbName   = "loyalty"
+)
+
+// NewConfig starts an ephemeral Postgres container, applies the application's migrations against
+// it, and returns the config.PGConf pointing at it. The container is terminated via t.Cleanup.
+func NewConfig(t *testing.T) config.PGConf {
+	t.Helper()
+
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     user,
+			"POSTGRES_PASSWORD": password,
+			"POSTGRES_DB":       dbName,
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(60 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatalf("failed to start postgres container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Logf("failed to terminate postgres container: %v", err)
+		}
+	})
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get postgres container host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("failed to get postgres container port: %v", err)
+	}
+
+	cfg := config.PGConf{
+		DatabaseDSN: fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			user, password, host, port.Port(), dbName),
+	}
+
+	if err := postgresql.ApplyMigrations(cfg, zerolog.Nop()); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	return cfg
+}
+
+// NewPool is NewConfig plus a ready connection pool, for tests that only need to run raw SQL
+// against the schema rather than go through the postgresql.DB methods.
+func NewPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	cfg := NewConfig(t)
+
+	pool, err := pgxpool.New(context.Background(), cfg.DatabaseDSN)
+	if err != nil {
+		t.Fatalf("failed to connect to test postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
@@ -8,57 +8,70 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ar4ie13/loyaltysystem/internal/metrics"
 	"github.com/ar4ie13/loyaltysystem/internal/requestor/config"
 	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
-const pollSleepTime = 1 * time.Second
+const (
+	pollSleepTime   = 1 * time.Second
+	orderLease      = 30 * time.Second
+	janitorInterval = 30 * time.Second
+)
 
+// Requestor polls the repository for unprocessed orders and reports them to the accrual service.
+// id identifies this instance as the worker_id claiming orders, so several replicas can poll the
+// same table without claiming the same order twice.
 type Requestor struct {
-	orders     []string
+	id         string
 	conf       config.ReqConf
 	zlog       zerolog.Logger
 	repo       Repository
 	retryAfter int
+	metrics    *metrics.Collector
 }
 
 type Repository interface {
-	GetUnprocessedOrders(ctx context.Context, limit int) ([]string, error)
+	ClaimUnprocessedOrders(ctx context.Context, workerID string, limit int, leaseDuration time.Duration) ([]string, error)
+	ReleaseOrderLease(ctx context.Context, orderNum string) error
+	ResetStuckOrders(ctx context.Context) (int64, error)
 	UpdateOrderWithoutAccrual(ctx context.Context, orderNum string, status string) error
 	UpdateOrderWithAccrual(ctx context.Context, orderNum string, status string, accrual float64) error
 }
 
-func NewRequestor(conf config.ReqConf, zlog zerolog.Logger, repo Repository) *Requestor {
+func NewRequestor(conf config.ReqConf, zlog zerolog.Logger, repo Repository, mtr *metrics.Collector) *Requestor {
 	r := &Requestor{
-		orders:     make([]string, 0),
+		id:         uuid.NewString(),
 		conf:       conf,
 		zlog:       zlog,
 		repo:       repo,
 		retryAfter: 0,
+		metrics:    mtr,
 	}
 	go r.StartWorkers()
+	go r.janitorLoop()
 	return r
 }
 
 func (r *Requestor) StartWorkers() {
 	for {
-		var err error
 		wg := &sync.WaitGroup{}
 		ctx, cancel := context.WithCancel(context.Background())
-		r.orders, err = r.repo.GetUnprocessedOrders(context.Background(), r.conf.WorkerNum)
+		orders, err := r.repo.ClaimUnprocessedOrders(context.Background(), r.id, r.conf.WorkerNum, orderLease)
 		if err != nil {
-			r.zlog.Error().Err(err).Msg("unable to get unprocessed orders")
+			r.zlog.Error().Err(err).Msg("unable to claim unprocessed orders")
 		}
 
 		switch {
-		case len(r.orders) == 0:
+		case len(orders) == 0:
 			time.Sleep(pollSleepTime)
 			r.zlog.Debug().Msgf("no unprocessed orders, sleeping %v seconds...", pollSleepTime.Seconds())
 		default:
-			for workerID := 0; workerID < len(r.orders); workerID++ {
+			for _, orderNum := range orders {
 				wg.Add(1)
-				go r.executeRequestWorker(ctx, wg, workerID, cancel)
+				go r.executeRequestWorker(ctx, wg, orderNum, cancel)
 			}
 
 			wg.Wait()
@@ -75,20 +88,48 @@ func (r *Requestor) StartWorkers() {
 	}
 }
 
-func (r *Requestor) executeRequestWorker(ctx context.Context, wg *sync.WaitGroup, id int, cancel context.CancelFunc) {
+// janitorLoop periodically clears the lease of orders whose locked_until has passed without
+// reaching a final status, e.g. because the worker holding them crashed
+func (r *Requestor) janitorLoop() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		reset, err := r.repo.ResetStuckOrders(context.Background())
+		if err != nil {
+			r.zlog.Error().Err(err).Msg("unable to reset stuck orders")
+			continue
+		}
+		if reset > 0 {
+			r.zlog.Debug().Msgf("janitor reset %d stuck orders", reset)
+		}
+	}
+}
+
+// releaseLease frees a claimed order's lease without waiting for it to expire, so another worker
+// can retry it right away
+func (r *Requestor) releaseLease(orderNum string) {
+	if err := r.repo.ReleaseOrderLease(context.Background(), orderNum); err != nil {
+		r.zlog.Err(err).Msgf("unable to release lease for order %s", orderNum)
+	}
+}
+
+func (r *Requestor) executeRequestWorker(ctx context.Context, wg *sync.WaitGroup, orderNum string, cancel context.CancelFunc) {
 	defer wg.Done()
 	select {
 	case <-ctx.Done():
-		r.zlog.Debug().Msgf("worker %d cancelled while processing order %s", id, r.orders[id])
+		r.zlog.Debug().Msgf("worker cancelled while processing order %s", orderNum)
+		r.releaseLease(orderNum)
 		return
 	default:
 	}
-	r.zlog.Debug().Msgf("worker %d processing order %s", id, r.orders[id])
+	r.zlog.Debug().Msgf("worker processing order %s", orderNum)
 
 	client := resty.New()
-	resp, err := client.R().Get(r.conf.AccrualAddr + "/api/orders/" + r.orders[id])
+	resp, err := client.R().Get(r.conf.AccrualAddr + "/api/orders/" + orderNum)
 	if err != nil {
-		r.zlog.Err(err).Msgf("worker %d unable to process order %s", id, r.orders[id])
+		r.zlog.Err(err).Msgf("unable to process order %s", orderNum)
+		r.releaseLease(orderNum)
 		return
 	}
 
@@ -104,6 +145,7 @@ func (r *Requestor) executeRequestWorker(ctx context.Context, wg *sync.WaitGroup
 				err = r.repo.UpdateOrderWithoutAccrual(ctx, accrualResponse.OrderNumber, accrualResponse.Status)
 				if err != nil {
 					r.zlog.Err(err).Msg("unable to update order")
+					r.metrics.ObserveRequestorOutcome("error")
 					return
 				}
 			} else {
@@ -112,27 +154,34 @@ func (r *Requestor) executeRequestWorker(ctx context.Context, wg *sync.WaitGroup
 			err = r.repo.UpdateOrderWithAccrual(ctx, accrualResponse.OrderNumber, accrualResponse.Status, accrual)
 			if err != nil {
 				r.zlog.Err(err).Msg("unable to update order")
+				r.metrics.ObserveRequestorOutcome("error")
 				return
 			}
 		}
 
-		r.zlog.Debug().Msgf("worker %d processes order %s", id, r.orders[id])
+		r.zlog.Debug().Msgf("processed order %s", orderNum)
+		r.metrics.ObserveRequestorOutcome("ok")
 	case http.StatusNoContent:
-		r.zlog.Debug().Msgf("worker %d: order %s wasn't found in accrual", id, r.orders[id])
+		r.zlog.Debug().Msgf("order %s wasn't found in accrual", orderNum)
+		r.releaseLease(orderNum)
+		r.metrics.ObserveRequestorOutcome("no_content")
 		return
 	case http.StatusTooManyRequests:
 		if sleepTimeStr := resp.Header().Get("Retry-After"); sleepTimeStr != "" {
 			r.retryAfter, err = strconv.Atoi(sleepTimeStr)
 			if err != nil {
 				r.zlog.Err(err).Msgf("unable to parse retry after %s", sleepTimeStr)
-				return
 			}
 		}
-		r.zlog.Debug().Msgf("worker %d found %d Status Code. Cancelling all workers", resp.StatusCode(), id)
+		r.zlog.Debug().Msgf("found %d status code for order %s, cancelling all workers", resp.StatusCode(), orderNum)
+		r.releaseLease(orderNum)
+		r.metrics.ObserveRequestorOutcome("too_many_requests")
 		cancel()
 		return
 	default:
-		r.zlog.Err(err).Msgf("accrual service returned status %d", resp.StatusCode())
+		r.zlog.Err(err).Msgf("accrual service returned status %d for order %s", resp.StatusCode(), orderNum)
+		r.releaseLease(orderNum)
+		r.metrics.ObserveRequestorOutcome("error")
 		return
 	}
 }
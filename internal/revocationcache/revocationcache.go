@@ -0,0 +1,83 @@
+package revocationcache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache is the pluggable backend for short-TTL access-token revocation results, keyed by jti. The
+// auth middleware consults it before falling back to a DB lookup, so a revocation check doesn't
+// cost a round trip on every request. The default, MemoryCache, is suitable for a single instance.
+type Cache interface {
+	// Get reports the cached revocation result for jti, and whether the entry is present and unexpired
+	Get(jti string) (revoked bool, ok bool)
+	// Set records jti's revocation result, valid for ttl
+	Set(jti string, revoked bool, ttl time.Duration)
+}
+
+// entry is a single cached revocation result
+type entry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// sweepInterval controls how often MemoryCache discards expired entries, bounding its steady-state
+// size to roughly the number of distinct jtis seen in one sweep interval rather than letting it
+// grow for as long as the process runs
+const sweepInterval = time.Minute
+
+// MemoryCache is an in-process, map-backed Cache
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// NewMemoryCache creates an empty in-memory revocation Cache and starts its background sweep loop
+func NewMemoryCache() *MemoryCache {
+	c := &MemoryCache{entries: make(map[string]entry)}
+	go c.sweepLoop()
+	return c
+}
+
+// sweepLoop periodically discards expired entries
+func (c *MemoryCache) sweepLoop() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.sweep()
+	}
+}
+
+// sweep removes every entry whose TTL has passed
+func (c *MemoryCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for jti, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, jti)
+		}
+	}
+}
+
+// Get implements Cache
+func (c *MemoryCache) Get(jti string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[jti]
+	if !ok || time.Now().After(e.expiresAt) {
+		return false, false
+	}
+	return e.revoked, true
+}
+
+// Set implements Cache
+func (c *MemoryCache) Set(jti string, revoked bool, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[jti] = entry{revoked: revoked, expiresAt: time.Now().Add(ttl)}
+}
@@ -0,0 +1,32 @@
+package role
+
+import "context"
+
+// Role identifies a user's privilege level within the system
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleAdmin   Role = "admin"
+	RoleSupport Role = "support"
+)
+
+// ctxKey is an unexported type to avoid collisions with context keys from other packages
+type ctxKey int
+
+const callerRoleKey ctxKey = iota
+
+// WithContext returns a context carrying the caller's role, so service-layer methods can reject
+// privilege escalations regardless of what an HTTP handler believes the caller is allowed to do
+func WithContext(ctx context.Context, r Role) context.Context {
+	return context.WithValue(ctx, callerRoleKey, r)
+}
+
+// FromContext extracts the caller's role from context, defaulting to RoleUser if absent
+func FromContext(ctx context.Context) Role {
+	r, ok := ctx.Value(callerRoleKey).(Role)
+	if !ok {
+		return RoleUser
+	}
+	return r
+}
@@ -2,27 +2,35 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/ar4ie13/loyaltysystem/internal/apperrors"
 	"github.com/ar4ie13/loyaltysystem/internal/models"
+	"github.com/ar4ie13/loyaltysystem/internal/role"
+	"github.com/ar4ie13/loyaltysystem/internal/urlsafety"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 // Service is a main object of service layer
 type Service struct {
-	repo Repository
-	zlog zerolog.Logger
+	repo            Repository
+	zlog            zerolog.Logger
+	refreshTokenTTL time.Duration
 }
 
 // NewService constructs new service object
-func NewService(repo Repository, zlog zerolog.Logger) *Service {
+func NewService(repo Repository, zlog zerolog.Logger, refreshTokenTTL time.Duration) *Service {
 	return &Service{
-		repo: repo,
-		zlog: zlog,
+		repo:            repo,
+		zlog:            zlog,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
@@ -35,6 +43,24 @@ type Repository interface {
 	GetBalance(ctx context.Context, user uuid.UUID) (models.User, error)
 	PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNum string, withdrawn float64) error
 	GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error)
+	GetUserByExternalSub(ctx context.Context, provider, sub string) (models.User, error)
+	CreateUserWithExternalSub(ctx context.Context, user models.User, provider, sub string) error
+	CreateRefreshToken(ctx context.Context, rt models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, jti string) (models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldJTI string, next models.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, jti string) error
+	RevokeRefreshTokenFamily(ctx context.Context, jti string) error
+	RevokeJTI(ctx context.Context, jti string, until time.Time) error
+	IsJTIRevoked(ctx context.Context, jti string) (bool, error)
+	GetUserRole(ctx context.Context, userUUID uuid.UUID) (role.Role, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	AdminAdjustBalance(ctx context.Context, userUUID uuid.UUID, delta float64) error
+	RevokeAllUserRefreshTokens(ctx context.Context, userUUID uuid.UUID) error
+	CreateAuditLogEntry(ctx context.Context, entry models.AuditLogEntry) error
+	GetAuditLog(ctx context.Context, limit int) ([]models.AuditLogEntry, error)
+	CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, userUUID uuid.UUID) ([]models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, userUUID uuid.UUID, id int64) error
 }
 
 // checkLoginString is a helper to validate login string
@@ -59,6 +85,7 @@ func (s *Service) CreateUser(ctx context.Context, user models.User) error {
 	}
 
 	user.UUID = uuid.New()
+	user.Role = role.RoleUser
 
 	err := s.repo.CreateUser(ctx, user)
 	if err != nil {
@@ -95,39 +122,37 @@ func (s *Service) PutUserOrder(ctx context.Context, user uuid.UUID, order string
 	return nil
 }
 
+// doubledLuhnDigit maps a digit to its Luhn-doubled value, avoiding a `*2; if >9 subtract 9` branch
+var doubledLuhnDigit = [10]int{0, 2, 4, 6, 8, 1, 3, 5, 7, 9}
+
 // checkOrderNumber checks order number for Luhn algorithm compliance
 func (s *Service) checkOrderNumber(order string) bool {
+	return checkLuhn(order)
+}
+
+// checkLuhn validates a Luhn checksum in a single, allocation-free pass over the input bytes
+func checkLuhn(order string) bool {
 	if len(order) < 2 {
 		return false
 	}
 
-	t := time.Now()
-	digits := make([]int, len(order))
-	for i, char := range order {
-		digit, err := strconv.Atoi(string(char))
-		if err != nil {
-			return false
-		}
-		digits[i] = digit
-	}
-
 	sum := 0
-	isSecond := false
+	double := false
 
-	for i := len(digits) - 1; i >= 0; i-- {
-		digit := digits[i]
+	for i := len(order) - 1; i >= 0; i-- {
+		b := order[i]
+		if b < '0' || b > '9' {
+			return false
+		}
 
-		if isSecond {
-			digit = digit * 2
-			if digit > 9 {
-				digit = digit - 9
-			}
+		d := int(b - '0')
+		if double {
+			d = doubledLuhnDigit[d]
 		}
 
-		sum += digit
-		isSecond = !isSecond
+		sum += d
+		double = !double
 	}
-	fmt.Println(time.Since(t))
 
 	return sum%10 == 0
 }
@@ -158,7 +183,7 @@ func (s *Service) GetBalance(ctx context.Context, user uuid.UUID) (models.User,
 // PutUserWithdrawnOrder used for registering user's order with withdrawn
 func (s *Service) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, orderNum string, withdrawn float64) error {
 	if withdrawn <= 0 {
-		return fmt.Errorf("withdrawn must be greater than zero")
+		return apperrors.ErrWithdrawnNotPositive
 	}
 
 	if user == uuid.Nil {
@@ -176,6 +201,108 @@ func (s *Service) PutUserWithdrawnOrder(ctx context.Context, user uuid.UUID, ord
 	return nil
 }
 
+// LoginOrCreateBySub logs in the user linked to the given IdP subject, creating a new account on first login
+func (s *Service) LoginOrCreateBySub(ctx context.Context, provider, sub, login string) (models.User, error) {
+	user, err := s.repo.GetUserByExternalSub(ctx, provider, sub)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, apperrors.ErrUserNotFound) {
+		return models.User{}, err
+	}
+
+	user = models.User{
+		UUID:  uuid.New(),
+		Login: login,
+		Role:  role.RoleUser,
+	}
+
+	if err = s.repo.CreateUserWithExternalSub(ctx, user, provider, sub); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// hashRefreshToken returns the SHA-256 hex digest of a raw opaque refresh token. Only the digest is
+// ever persisted as refresh_tokens.jti, so reading or leaking the table doesn't hand out live
+// sessions the way storing the raw token would.
+func hashRefreshToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueRefreshToken creates a new refresh token for the user, used right after login/register.
+// rawToken is the opaque value the caller will present on the next refresh; only its hash is
+// stored. userAgent and ip record where the session was created, the same way a sessions table's
+// user_agent/ip columns would.
+func (s *Service) IssueRefreshToken(ctx context.Context, userUUID uuid.UUID, rawToken, userAgent, ip string) (models.RefreshToken, error) {
+	rt := models.RefreshToken{
+		JTI:       hashRefreshToken(rawToken),
+		UserUUID:  userUUID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+
+	if err := s.repo.CreateRefreshToken(ctx, rt); err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	return rt, nil
+}
+
+// RefreshSession rotates a refresh token and returns its replacement, cascade-revoking the whole
+// token family if the presented token has already been rotated (a sign of token theft/reuse).
+// presentedRaw and nextRaw are the opaque values the client presented/will present; only their
+// hashes are looked up or stored.
+func (s *Service) RefreshSession(ctx context.Context, presentedRaw, nextRaw, userAgent, ip string) (models.RefreshToken, error) {
+	presentedHash := hashRefreshToken(presentedRaw)
+
+	rt, err := s.repo.GetRefreshToken(ctx, presentedHash)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	if rt.RevokedAt != nil {
+		if revokeErr := s.repo.RevokeRefreshTokenFamily(ctx, presentedHash); revokeErr != nil {
+			return models.RefreshToken{}, revokeErr
+		}
+		return models.RefreshToken{}, apperrors.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		return models.RefreshToken{}, apperrors.ErrRefreshTokenExpired
+	}
+
+	next := models.RefreshToken{
+		JTI:       hashRefreshToken(nextRaw),
+		UserUUID:  rt.UserUUID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+
+	if err = s.repo.RotateRefreshToken(ctx, presentedHash, next); err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	return next, nil
+}
+
+// Logout revokes the presented refresh token and blacklists the access token's jti until it expires
+func (s *Service) Logout(ctx context.Context, refreshRaw, accessJTI string, accessExpiresAt time.Time) error {
+	if err := s.repo.RevokeRefreshToken(ctx, hashRefreshToken(refreshRaw)); err != nil {
+		return err
+	}
+	return s.repo.RevokeJTI(ctx, accessJTI, accessExpiresAt)
+}
+
+// IsTokenRevoked reports whether an access token's jti has been blacklisted
+func (s *Service) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.repo.IsJTIRevoked(ctx, jti)
+}
+
 // GetUserWithdrawals returns all user's orders with withdrawn
 func (s *Service) GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([]models.Order, error) {
 	if userUUID == uuid.Nil {
@@ -187,3 +314,142 @@ func (s *Service) GetUserWithdrawals(ctx context.Context, userUUID uuid.UUID) ([
 	}
 	return orders, nil
 }
+
+// GetUserRole returns the role currently assigned to a user, used to re-embed the claim on token refresh
+func (s *Service) GetUserRole(ctx context.Context, userUUID uuid.UUID) (role.Role, error) {
+	return s.repo.GetUserRole(ctx, userUUID)
+}
+
+// isPrivileged reports whether a role may access the admin subsystem
+func isPrivileged(r role.Role) bool {
+	return r == role.RoleAdmin || r == role.RoleSupport
+}
+
+// ListUsers returns every registered user. The caller's role is threaded through ctx so this check
+// holds regardless of what an HTTP handler believes the caller is allowed to do.
+func (s *Service) ListUsers(ctx context.Context) ([]models.User, error) {
+	if !isPrivileged(role.FromContext(ctx)) {
+		return nil, apperrors.ErrForbidden
+	}
+	return s.repo.ListUsers(ctx)
+}
+
+// AdminAdjustBalance applies a manual balance adjustment to a user's account and records the action
+// in the audit log, restricted to admin/support callers
+func (s *Service) AdminAdjustBalance(ctx context.Context, actor, target uuid.UUID, delta float64, reason string) error {
+	if !isPrivileged(role.FromContext(ctx)) {
+		return apperrors.ErrForbidden
+	}
+
+	if target == uuid.Nil {
+		return apperrors.ErrInvalidUserUUID
+	}
+
+	if err := s.repo.AdminAdjustBalance(ctx, target, delta); err != nil {
+		return err
+	}
+
+	entry := models.AuditLogEntry{
+		ActorUUID:  actor,
+		Action:     "adjust_balance",
+		TargetUUID: target,
+		Details:    fmt.Sprintf("delta=%.2f reason=%s", delta, reason),
+	}
+	return s.repo.CreateAuditLogEntry(ctx, entry)
+}
+
+// AdminRevokeSessions revokes every active refresh token belonging to the target user and records
+// the action in the audit log, restricted to admin/support callers
+func (s *Service) AdminRevokeSessions(ctx context.Context, actor, target uuid.UUID) error {
+	if !isPrivileged(role.FromContext(ctx)) {
+		return apperrors.ErrForbidden
+	}
+
+	if err := s.repo.RevokeAllUserRefreshTokens(ctx, target); err != nil {
+		return err
+	}
+
+	entry := models.AuditLogEntry{
+		ActorUUID:  actor,
+		Action:     "revoke_sessions",
+		TargetUUID: target,
+	}
+	return s.repo.CreateAuditLogEntry(ctx, entry)
+}
+
+// GetAuditLog returns the most recent administrative actions, restricted to admin/support callers
+func (s *Service) GetAuditLog(ctx context.Context, limit int) ([]models.AuditLogEntry, error) {
+	if !isPrivileged(role.FromContext(ctx)) {
+		return nil, apperrors.ErrForbidden
+	}
+	return s.repo.GetAuditLog(ctx, limit)
+}
+
+// generateWebhookSecret creates a cryptographically random per-subscription secret, used by the
+// Dispatcher to HMAC-sign outbound webhook payloads
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// isValidWebhookURL reports whether rawURL is an absolute http(s) URL whose host resolves only to
+// public addresses, so a subscription can't be used to make the Dispatcher hit an internal service
+// or the cloud metadata endpoint
+func isValidWebhookURL(rawURL string) bool {
+	return urlsafety.IsSafeWebhookURL(rawURL)
+}
+
+// CreateWebhookSubscription registers a new callback URL that receives the caller's own order and
+// balance events
+func (s *Service) CreateWebhookSubscription(ctx context.Context, userUUID uuid.UUID, rawURL string) (models.WebhookSubscription, error) {
+	if userUUID == uuid.Nil {
+		return models.WebhookSubscription{}, apperrors.ErrInvalidUserUUID
+	}
+	if !isValidWebhookURL(rawURL) {
+		return models.WebhookSubscription{}, apperrors.ErrInvalidWebhookURL
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	return s.repo.CreateWebhookSubscription(ctx, models.WebhookSubscription{UserUUID: &userUUID, URL: rawURL, Secret: secret})
+}
+
+// AdminCreateWebhookSubscription registers a global callback URL that receives every user's order
+// and balance events, restricted to admin/support callers
+func (s *Service) AdminCreateWebhookSubscription(ctx context.Context, rawURL string) (models.WebhookSubscription, error) {
+	if !isPrivileged(role.FromContext(ctx)) {
+		return models.WebhookSubscription{}, apperrors.ErrForbidden
+	}
+	if !isValidWebhookURL(rawURL) {
+		return models.WebhookSubscription{}, apperrors.ErrInvalidWebhookURL
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	return s.repo.CreateWebhookSubscription(ctx, models.WebhookSubscription{URL: rawURL, Secret: secret})
+}
+
+// ListWebhookSubscriptions returns every webhook subscription owned by a user
+func (s *Service) ListWebhookSubscriptions(ctx context.Context, userUUID uuid.UUID) ([]models.WebhookSubscription, error) {
+	if userUUID == uuid.Nil {
+		return nil, apperrors.ErrInvalidUserUUID
+	}
+	return s.repo.ListWebhookSubscriptions(ctx, userUUID)
+}
+
+// DeleteWebhookSubscription removes a webhook subscription owned by a user
+func (s *Service) DeleteWebhookSubscription(ctx context.Context, userUUID uuid.UUID, id int64) error {
+	if userUUID == uuid.Nil {
+		return apperrors.ErrInvalidUserUUID
+	}
+	return s.repo.DeleteWebhookSubscription(ctx, userUUID, id)
+}
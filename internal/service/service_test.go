@@ -0,0 +1,117 @@
+package service
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// naiveLuhn is a reference Luhn implementation used to cross-check checkLuhn
+func naiveLuhn(order string) bool {
+	if len(order) < 2 {
+		return false
+	}
+
+	sum := 0
+	isSecond := false
+
+	for i := len(order) - 1; i >= 0; i-- {
+		digit, err := strconv.Atoi(string(order[i]))
+		if err != nil {
+			return false
+		}
+
+		if isSecond {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		isSecond = !isSecond
+	}
+
+	return sum%10 == 0
+}
+
+// genLuhnNumber builds a string of n random digits that satisfies the Luhn checksum
+func genLuhnNumber(r *rand.Rand, n int) string {
+	digits := make([]byte, n)
+	for i := 0; i < n-1; i++ {
+		digits[i] = byte('0' + r.Intn(10))
+	}
+
+	sum := 0
+	isSecond := true
+	for i := n - 2; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if isSecond {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		isSecond = !isSecond
+	}
+	digits[n-1] = byte('0' + (10-sum%10)%10)
+
+	return string(digits)
+}
+
+func TestCheckLuhn_ValidNumbers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for n := 2; n <= 30; n++ {
+		order := genLuhnNumber(r, n)
+		if !checkLuhn(order) {
+			t.Errorf("checkLuhn(%q) = false, want true (naive says %v)", order, naiveLuhn(order))
+		}
+	}
+}
+
+func TestCheckLuhn_MatchesNaiveReference(t *testing.T) {
+	f := func(order string) bool {
+		return checkLuhn(order) == naiveLuhn(order)
+	}
+
+	cfg := &quick.Config{
+		MaxCount: 2000,
+		Values: func(values []reflect.Value, r *rand.Rand) {
+			n := r.Intn(20)
+			var sb strings.Builder
+			for i := 0; i < n; i++ {
+				sb.WriteByte(byte('0' + r.Intn(12))) // occasionally emits non-digit noise
+			}
+			values[0] = reflect.ValueOf(sb.String())
+		},
+	}
+
+	if err := quick.Check(f, cfg); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckLuhn_RejectsNonDigits(t *testing.T) {
+	if checkLuhn("12a4") {
+		t.Error("checkLuhn should reject non-digit input")
+	}
+}
+
+func TestCheckLuhn_RejectsTooShort(t *testing.T) {
+	if checkLuhn("7") {
+		t.Error("checkLuhn should reject single-digit input")
+	}
+}
+
+func BenchmarkCheckLuhn(b *testing.B) {
+	const order = "79927398713"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		checkLuhn(order)
+	}
+}
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+// WebhooksConf configures the outbound webhook Dispatcher
+type WebhooksConf struct {
+	WorkerNum      int
+	MaxAttempts    int
+	BaseBackoff    time.Duration
+	MaxBackoff     time.Duration
+	RequestTimeout time.Duration
+}
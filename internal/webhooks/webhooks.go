@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ar4ie13/loyaltysystem/internal/models"
+	"github.com/ar4ie13/loyaltysystem/internal/urlsafety"
+	"github.com/ar4ie13/loyaltysystem/internal/webhooks/config"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	pollSleepTime   = 1 * time.Second
+	deliveryLease   = 30 * time.Second
+	janitorInterval = 30 * time.Second
+	signatureHeader = "X-Webhook-Signature"
+	eventHeader     = "X-Webhook-Event"
+)
+
+// Dispatcher polls the repository for due webhook_events and POSTs them to their subscription's
+// URL. id identifies this instance as the worker_id claiming events, so several replicas can poll
+// the same table without delivering the same event twice.
+type Dispatcher struct {
+	id   string
+	conf config.WebhooksConf
+	zlog zerolog.Logger
+	repo Repository
+}
+
+// Repository is the persistence surface the Dispatcher needs from the transactional outbox
+type Repository interface {
+	ClaimPendingWebhookEvents(ctx context.Context, workerID string, limit int, leaseDuration time.Duration, maxAttempts int) ([]models.WebhookDelivery, error)
+	MarkWebhookEventDelivered(ctx context.Context, id int64) error
+	MarkWebhookEventFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time) error
+	ResetStuckWebhookEvents(ctx context.Context) (int64, error)
+}
+
+// NewDispatcher constructs a Dispatcher and starts its worker and janitor loops
+func NewDispatcher(conf config.WebhooksConf, zlog zerolog.Logger, repo Repository) *Dispatcher {
+	d := &Dispatcher{
+		id:   uuid.NewString(),
+		conf: conf,
+		zlog: zlog,
+		repo: repo,
+	}
+	go d.StartWorkers()
+	go d.janitorLoop()
+	return d
+}
+
+// StartWorkers polls the outbox for due events and delivers each of them, sleeping between polls
+// when the outbox is empty
+func (d *Dispatcher) StartWorkers() {
+	for {
+		deliveries, err := d.repo.ClaimPendingWebhookEvents(context.Background(), d.id, d.conf.WorkerNum, deliveryLease, d.conf.MaxAttempts)
+		if err != nil {
+			d.zlog.Error().Err(err).Msg("unable to claim pending webhook events")
+		}
+
+		if len(deliveries) == 0 {
+			time.Sleep(pollSleepTime)
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			d.deliver(context.Background(), delivery)
+		}
+	}
+}
+
+// janitorLoop periodically clears the lease of events whose locked_until has passed without being
+// delivered, e.g. because the dispatcher replica holding them crashed
+func (d *Dispatcher) janitorLoop() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		reset, err := d.repo.ResetStuckWebhookEvents(context.Background())
+		if err != nil {
+			d.zlog.Error().Err(err).Msg("unable to reset stuck webhook events")
+			continue
+		}
+		if reset > 0 {
+			d.zlog.Debug().Msgf("janitor reset %d stuck webhook events", reset)
+		}
+	}
+}
+
+// sign computes the hex-encoded HMAC-SHA256 of payload keyed by the subscription's secret, so the
+// receiver can verify the event actually came from this system
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff computes the delay before the next retry, doubling per attempt and capped at MaxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.conf.BaseBackoff * time.Duration(math.Pow(2, float64(attempts)))
+	if delay > d.conf.MaxBackoff {
+		return d.conf.MaxBackoff
+	}
+	return delay
+}
+
+// blockUnsafeRedirects refuses to follow a redirect whose target isn't a safe webhook URL, so a
+// subscription that passed isValidWebhookURL at creation time can't be used to reach an internal
+// host by redirecting the delivery request to it
+func blockUnsafeRedirects(req *http.Request, _ []*http.Request) error {
+	if !urlsafety.IsSafeWebhookURL(req.URL.String()) {
+		return fmt.Errorf("refusing to follow redirect to unsafe host %q", req.URL.Hostname())
+	}
+	return nil
+}
+
+// deliver POSTs a single claimed event to its subscription's URL, marking it delivered on success
+// or rescheduling it with exponential backoff on failure, up to MaxAttempts
+func (d *Dispatcher) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	if !urlsafety.IsSafeWebhookURL(delivery.URL) {
+		d.zlog.Error().Msgf("webhook event %d targets an unsafe URL, giving up", delivery.ID)
+		if markErr := d.repo.MarkWebhookEventFailed(ctx, delivery.ID, d.conf.MaxAttempts, time.Now()); markErr != nil {
+			d.zlog.Err(markErr).Msgf("unable to reschedule webhook event %d", delivery.ID)
+		}
+		return
+	}
+
+	client := resty.New().SetTimeout(d.conf.RequestTimeout).SetRedirectPolicy(resty.RedirectPolicyFunc(blockUnsafeRedirects))
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(eventHeader, delivery.EventType).
+		SetHeader(signatureHeader, sign(delivery.Secret, delivery.Payload)).
+		SetBody(delivery.Payload).
+		Post(delivery.URL)
+
+	if err == nil && resp.IsSuccess() {
+		if markErr := d.repo.MarkWebhookEventDelivered(ctx, delivery.ID); markErr != nil {
+			d.zlog.Err(markErr).Msgf("unable to mark webhook event %d delivered", delivery.ID)
+		}
+		return
+	}
+
+	if err != nil {
+		d.zlog.Err(err).Msgf("unable to deliver webhook event %d", delivery.ID)
+	} else {
+		d.zlog.Debug().Msgf("webhook event %d rejected with status %d", delivery.ID, resp.StatusCode())
+	}
+
+	attempts := delivery.Attempts + 1
+	nextAttemptAt := time.Now()
+	if attempts < d.conf.MaxAttempts {
+		nextAttemptAt = nextAttemptAt.Add(d.backoff(attempts))
+	} else {
+		d.zlog.Error().Msgf("webhook event %d exhausted %d delivery attempts, giving up", delivery.ID, attempts)
+	}
+
+	if markErr := d.repo.MarkWebhookEventFailed(ctx, delivery.ID, attempts, nextAttemptAt); markErr != nil {
+		d.zlog.Err(markErr).Msgf("unable to reschedule webhook event %d", delivery.ID)
+	}
+}